@@ -0,0 +1,299 @@
+package glados
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	auditNamespace = "glados.audit"
+	// auditBucketSize is time span of bucket listing entry ids recorded in it.
+	// recording entry rewrites only the bucket of the time
+	auditBucketSize = time.Hour
+	// auditPrunedKey is key of oldest bucket not pruned yet
+	auditPrunedKey = "pruned"
+	// auditPruneInterval is how often expired buckets are pruned
+	auditPruneInterval = time.Hour
+	// defaultAuditRetention is how long entries are kept
+	defaultAuditRetention = 90 * 24 * time.Hour
+)
+
+// kinds of audit entry
+const (
+	AuditCommand    = "command"
+	AuditWebhook    = "webhook"
+	AuditPrivileged = "privileged"
+	AuditDenied     = "denied"
+)
+
+// AuditEntry is record of who told the bot to do what, when
+type AuditEntry struct {
+	ID        string    `json:"id"`
+	Time      time.Time `json:"time"`
+	Kind      string    `json:"kind"`
+	Adapter   string    `json:"adapter,omitempty"`
+	User      string    `json:"user,omitempty"`
+	Channel   string    `json:"channel,omitempty"`
+	Handler   string    `json:"handler,omitempty"`
+	Arguments string    `json:"arguments,omitempty"`
+	Detail    string    `json:"detail,omitempty"`
+}
+
+// AuditFilter is condition of entries queried. empty field matches every entry
+type AuditFilter struct {
+	Kind    string
+	User    string
+	Channel string
+	Since   time.Time
+	Limit   int
+}
+
+func (f AuditFilter) match(entry AuditEntry) bool {
+	return (f.Kind == "" || f.Kind == entry.Kind) &&
+		(f.User == "" || normalizeUser(f.User) == normalizeUser(entry.User)) &&
+		(f.Channel == "" || strings.EqualFold(strings.TrimPrefix(f.Channel, "#"), entry.Channel)) &&
+		(f.Since.IsZero() || !entry.Time.Before(f.Since))
+}
+
+// Audit is audit logger saving entries to storage.
+// entries older than GLADOS_AUDIT_RETENTION are dropped
+type Audit struct {
+	mu        sync.Mutex
+	context   Context
+	lastID    int64
+	lastPrune time.Time
+}
+
+// NewAudit is create audit logger instance
+func NewAudit(c Context) *Audit {
+	return &Audit{
+		context: c,
+	}
+}
+
+func auditKey(id string) string {
+	return "entry:" + id
+}
+
+func auditBucket(t time.Time) int64 {
+	return t.UnixNano() / int64(auditBucketSize)
+}
+
+func auditBucketKey(bucket int64) string {
+	return "bucket:" + strconv.FormatInt(bucket, 36)
+}
+
+func (a *Audit) retention() time.Duration {
+	retention, err := time.ParseDuration(a.context.Env("GLADOS_AUDIT_RETENTION", defaultAuditRetention.String()))
+	if err != nil || retention <= 0 {
+		return defaultAuditRetention
+	}
+	return retention
+}
+
+// Record is save entry. failure is only logged not to block audited action
+func (a *Audit) Record(entry AuditEntry) {
+	if err := a.record(entry); err != nil {
+		a.context.Logger().Warnln("glados: record audit failed. " + err.Error())
+	}
+}
+
+func (a *Audit) record(entry AuditEntry) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if entry.Time.IsZero() {
+		entry.Time = time.Now()
+	}
+	id := entry.Time.UnixNano()
+	if id <= a.lastID {
+		id = a.lastID + 1
+	}
+	a.lastID = id
+	entry.ID = strconv.FormatInt(id, 36)
+	a.context.Logger().Debugln(fmt.Sprintf("glados: audit: %s %s in %s %s %s %s",
+		entry.Kind, entry.User, entry.Channel, entry.Handler, entry.Arguments, entry.Detail))
+
+	storage := a.context.Storage()
+	if storage == nil {
+		return errors.New("glados: storage is not set")
+	}
+	if err := storage.Save(auditNamespace, auditKey(entry.ID), entry); err != nil {
+		return err
+	}
+	key := auditBucketKey(auditBucket(entry.Time))
+	var ids []string
+	if _, err := storage.Load(auditNamespace, key, &ids); err != nil {
+		return err
+	}
+	if err := storage.Save(auditNamespace, key, append(ids, entry.ID)); err != nil {
+		return err
+	}
+	if entry.Time.Sub(a.lastPrune) < auditPruneInterval {
+		return nil
+	}
+	a.lastPrune = entry.Time
+	return a.prune(entry.Time)
+}
+
+// prune is delete entries and buckets older than retention
+func (a *Audit) prune(now time.Time) error {
+	storage := a.context.Storage()
+	expired := auditBucket(now.Add(-a.retention()))
+	var pruned int64
+	exist, err := storage.Load(auditNamespace, auditPrunedKey, &pruned)
+	if err != nil {
+		return err
+	}
+	if !exist {
+		// nothing is recorded before first prune
+		pruned = expired
+	}
+	for ; pruned < expired; pruned++ {
+		var ids []string
+		exist, err := storage.Load(auditNamespace, auditBucketKey(pruned), &ids)
+		if err != nil {
+			return err
+		}
+		if !exist {
+			continue
+		}
+		for _, id := range ids {
+			if err := storage.Delete(auditNamespace, auditKey(id)); err != nil {
+				return err
+			}
+		}
+		if err := storage.Delete(auditNamespace, auditBucketKey(pruned)); err != nil {
+			return err
+		}
+	}
+	return storage.Save(auditNamespace, auditPrunedKey, pruned)
+}
+
+// Entries is return entries matched filter, newest first
+func (a *Audit) Entries(filter AuditFilter) ([]AuditEntry, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	storage := a.context.Storage()
+	now := time.Now()
+	since := now.Add(-a.retention())
+	if filter.Since.Before(since) {
+		filter.Since = since
+	}
+	entries := []AuditEntry{}
+	for bucket := auditBucket(now); bucket >= auditBucket(filter.Since); bucket-- {
+		var ids []string
+		if _, err := storage.Load(auditNamespace, auditBucketKey(bucket), &ids); err != nil {
+			return nil, err
+		}
+		for i := len(ids) - 1; i >= 0; i-- {
+			if filter.Limit > 0 && len(entries) >= filter.Limit {
+				return entries, nil
+			}
+			entry := AuditEntry{}
+			exist, err := storage.Load(auditNamespace, auditKey(ids[i]), &entry)
+			if err != nil {
+				return nil, err
+			}
+			if exist && filter.match(entry) {
+				entries = append(entries, entry)
+			}
+		}
+	}
+	return entries, nil
+}
+
+// String is format entry as one line
+func (e AuditEntry) String() string {
+	fields := []string{e.Time.Format("2006-01-02 15:04:05"), e.Kind}
+	if e.User != "" {
+		fields = append(fields, "@"+e.User)
+	}
+	if e.Channel != "" && e.Adapter != "" && e.Adapter != DefaultChatAdapterName {
+		fields = append(fields, e.Adapter+":#"+e.Channel)
+	} else if e.Channel != "" {
+		fields = append(fields, "#"+e.Channel)
+	}
+	for _, field := range []string{e.Handler, e.Arguments, e.Detail} {
+		if field != "" {
+			fields = append(fields, field)
+		}
+	}
+	return strings.Join(fields, " ")
+}
+
+// ListAudit is create request handler listing entries matched kind, user, channel, since (RFC3339) and limit query parameters
+func ListAudit(c Context) RequestHandler {
+	return func(rc RequestContext) {
+		query := rc.Request().URL.Query()
+		filter := AuditFilter{
+			Kind:    query.Get("kind"),
+			User:    query.Get("user"),
+			Channel: query.Get("channel"),
+			Limit:   100,
+		}
+		if limit, err := strconv.Atoi(query.Get("limit")); err == nil && limit > 0 {
+			filter.Limit = limit
+		}
+		if since, err := time.Parse(time.RFC3339, query.Get("since")); err == nil {
+			filter.Since = since
+		}
+		entries, err := c.Audit().Entries(filter)
+		if err != nil {
+			c.Logger().Warnln("glados: load audit failed. " + err.Error())
+			rc.JSON(http.StatusInternalServerError, H{
+				"message": "glados: load audit failed",
+			})
+			return
+		}
+		rc.JSON(http.StatusOK, H{
+			"entries": entries,
+		})
+	}
+}
+
+// mountAudit is mount audit endpoint when GLADOS_AUDIT_ADMIN_TOKEN is set
+func mountAudit(c Context) {
+	token := c.Env("GLADOS_AUDIT_ADMIN_TOKEN", "")
+	if token == "" || c.Router() == nil {
+		return
+	}
+	c.Router().GET(c.Env("GLADOS_AUDIT_PATH", "/audit"), RequireToken(token, ListAudit(c)))
+}
+
+func (d *Dispatcher) registerAuditCommand() {
+	d.Command("audit [--user=] [--kind=] [--channel=] [--limit:int=20]", func(adapter ChatAdapter, message *ChatMessageEvent, args *CommandArgs) {
+		entries, err := d.context.Audit().Entries(AuditFilter{
+			Kind:    args.String("kind"),
+			User:    args.String("user"),
+			Channel: args.String("channel"),
+			Limit:   args.Int("limit"),
+		})
+		if err != nil {
+			d.context.Logger().Warnln("glados: load audit failed. " + err.Error())
+			d.say(adapter, message.Channel, "@"+message.User+" load audit failed")
+			return
+		}
+		if len(entries) <= 0 {
+			d.say(adapter, message.Channel, "no audit entry")
+			return
+		}
+		lines := make([]string, len(entries))
+		for i, entry := range entries {
+			lines[i] = entry.String()
+		}
+		d.say(adapter, message.Channel, strings.Join(lines, "\n"))
+	}, RequireRole(RoleAdmin), WithDescription("show recent commands, webhooks and privileged actions"))
+}
+
+// auditHandlerName is return name of handler recorded in audit entry
+func auditHandlerName(info HandlerInfo) string {
+	if info.Usage != "" {
+		return info.Usage
+	}
+	return info.Pattern
+}
@@ -0,0 +1,91 @@
+package glados_test
+
+import (
+	"os"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/astronoka/glados"
+	"github.com/astronoka/glados/gladostest"
+)
+
+func TestAuditRecordAndPrune(t *testing.T) {
+	os.Setenv("GLADOS_AUDIT_RETENTION", "24h")
+	defer os.Unsetenv("GLADOS_AUDIT_RETENTION")
+	h := gladostest.New()
+	audit := glados.NewAudit(h.Context)
+	now := time.Now()
+
+	audit.Record(glados.AuditEntry{Detail: "expired", Time: now.Add(-48 * time.Hour), Kind: glados.AuditCommand, User: "alice"})
+	audit.Record(glados.AuditEntry{Detail: "old", Time: now.Add(-3 * time.Hour), Kind: glados.AuditCommand, User: "alice"})
+	audit.Record(glados.AuditEntry{Detail: "denied", Time: now.Add(-2 * time.Hour), Kind: glados.AuditDenied, User: "bob"})
+	audit.Record(glados.AuditEntry{Detail: "new", Time: now.Add(-time.Minute), Kind: glados.AuditCommand, User: "alice"})
+
+	tests := []struct {
+		name   string
+		filter glados.AuditFilter
+		want   []string
+	}{
+		{"all", glados.AuditFilter{}, []string{"new", "denied", "old"}},
+		{"by kind", glados.AuditFilter{Kind: glados.AuditCommand}, []string{"new", "old"}},
+		{"by user", glados.AuditFilter{User: "bob"}, []string{"denied"}},
+		{"since", glados.AuditFilter{Since: now.Add(-150 * time.Minute)}, []string{"new", "denied"}},
+		{"limit", glados.AuditFilter{Limit: 2}, []string{"new", "denied"}},
+	}
+	for _, test := range tests {
+		entries, err := audit.Entries(test.filter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, entry := range entries {
+			got = append(got, entry.Detail)
+		}
+		if len(got) != len(test.want) {
+			t.Errorf("%s: got %v, want %v", test.name, got, test.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != test.want[i] {
+				t.Errorf("%s: got %v, want %v", test.name, got, test.want)
+				break
+			}
+		}
+	}
+
+	// widen retention so that entry left unpruned shows up
+	os.Setenv("GLADOS_AUDIT_RETENTION", "72h")
+	entries, err := audit.Entries(glados.AuditFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		if entry.Detail == "expired" {
+			t.Error("expired entry is not pruned")
+		}
+	}
+}
+
+func TestAuditConcurrentRecord(t *testing.T) {
+	h := gladostest.New()
+	audit := glados.NewAudit(h.Context)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			audit.Record(glados.AuditEntry{Kind: glados.AuditCommand, User: "user" + strconv.Itoa(i)})
+		}(i)
+	}
+	wg.Wait()
+	entries, err := audit.Entries(glados.AuditFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 20 {
+		t.Errorf("got %d entries, want 20", len(entries))
+	}
+}
@@ -1,15 +1,72 @@
 package glados
 
+import "time"
+
 // ChatBotMessageHandler is chat bot callback function
 type ChatBotMessageHandler func(adapter ChatAdapter, message *ChatMessageEvent)
 
+// ChatReactionHandler is chat bot callback function for reaction
+type ChatReactionHandler func(adapter ChatAdapter, reaction *ChatReactionEvent)
+
+// ChatActionHandler is chat bot callback function for clicked button or selected menu option
+type ChatActionHandler func(adapter ChatAdapter, action *ChatActionEvent)
+
 // ChatAdapter is glados chat interface
 type ChatAdapter interface {
-	PostTextMessage(channel, text string)
-	PostMessage(channel string, message *ChatMessage)
+	PostTextMessage(channel, text string) (ChatMessageRef, error)
+	PostMessage(channel string, message *ChatMessage) (ChatMessageRef, error)
+	PostThreadMessage(channel, threadID string, message *ChatMessage) (ChatMessageRef, error)
+	PostEphemeralMessage(channel, user string, message *ChatMessage) error
+	PostDirectMessage(user string, message *ChatMessage) (ChatMessageRef, error)
+	UpdateMessage(ref ChatMessageRef, message *ChatMessage) error
+	DeleteMessage(ref ChatMessageRef) error
+	AddReaction(ref ChatMessageRef, name string) error
+	RemoveReaction(ref ChatMessageRef, name string) error
+
+	Here(pattern string, handler ChatBotMessageHandler, options ...HandlerOption)
+	Respond(pattern string, handler ChatBotMessageHandler, options ...HandlerOption)
+	Reaction(pattern string, handler ChatReactionHandler, options ...HandlerOption)
+	Action(pattern string, handler ChatActionHandler, options ...HandlerOption)
+	SlashCommand(name string, handler ChatBotMessageHandler, options ...HandlerOption)
+}
+
+// DefaultChatAdapterName is name of chat adapter set by Context.SetChatAdapter
+const DefaultChatAdapterName = "default"
+
+// ChatAdapterWrapper is chat adapter wrapping registered adapter, such as responder of one request.
+// it is unwrapped to find name of adapter
+type ChatAdapterWrapper interface {
+	UnwrapChatAdapter() ChatAdapter
+}
+
+// HandlerInfo is description of registered handler
+type HandlerInfo struct {
+	Pattern     string
+	Respond     bool
+	Reaction    bool
+	Action      bool
+	Slash       bool
+	Usage       string
+	Description string
+	Roles       []string
+	Channels    []string
+}
 
-	Here(pattern string, handler ChatBotMessageHandler)
-	Respond(pattern string, handler ChatBotMessageHandler)
+// HandlerOption is optional setting of registered handler
+type HandlerOption func(*HandlerInfo)
+
+// WithHelp is set usage and description shown by help command
+func WithHelp(usage, description string) HandlerOption {
+	return func(info *HandlerInfo) {
+		info.Usage = usage
+		info.Description = description
+	}
+}
+
+// ChatMessageRef is reference to posted message
+type ChatMessageRef struct {
+	Channel string
+	ID      string
 }
 
 // MessageAuthor is message author
@@ -20,19 +77,121 @@ type MessageAuthor struct {
 	IconURL string
 }
 
-// ChatMessage is chat message
+// ChatMessage is chat message. Text is written in slack style markup,
+// and adapters without rich message support degrade it by Markdown or PlainText
 type ChatMessage struct {
 	Author       MessageAuthor
 	Title        string
 	TitleLinkURL string
 	Text         string
 	Color        string
+	Fields       []MessageField
+	ImageURL     string
+	Sections     []MessageSection
+	Context      []string
+	Footer       string
+	Timestamp    time.Time
+	Actions      []MessageAction
+}
+
+// IsPlainText is return true if message has only text
+func (m *ChatMessage) IsPlainText() bool {
+	return m.Author == MessageAuthor{} && m.Title == "" && m.TitleLinkURL == "" && m.Color == "" &&
+		len(m.Fields) <= 0 && m.ImageURL == "" && len(m.Sections) <= 0 && len(m.Context) <= 0 &&
+		m.Footer == "" && m.Timestamp.IsZero() && len(m.Actions) <= 0
 }
 
-// ChatMessageEvent is message from chat system
+// ChatMessageEvent is message from chat system.
+// Command is set for slash command, and Text is its arguments.
+// Values are attached by incoming middlewares, and Adapter is name of chat adapter received the event
 type ChatMessageEvent struct {
-	Channel string
-	User    string
-	Text    string
-	Matches [][]string
+	Adapter   string
+	Channel   string
+	User      string
+	Text      string
+	MessageID string
+	ThreadID  string
+	Command   string
+	Matches   [][]string
+	Values    map[string]interface{}
+}
+
+// Set is attach value to event
+func (e *ChatMessageEvent) Set(key string, value interface{}) {
+	if e.Values == nil {
+		e.Values = map[string]interface{}{}
+	}
+	e.Values[key] = value
+}
+
+// Value is return value attached to event, or nil
+func (e *ChatMessageEvent) Value(key string) interface{} {
+	return e.Values[key]
+}
+
+// ThreadRootID is return thread id to reply. it is message id if message is not in thread
+func (e *ChatMessageEvent) ThreadRootID() string {
+	if e.ThreadID != "" {
+		return e.ThreadID
+	}
+	return e.MessageID
+}
+
+// ChatReactionEvent is reaction added to or removed from message by user.
+// Reaction is emoji name without colons, and Channel is same form as ChatMessageRef channel
+type ChatReactionEvent struct {
+	Adapter   string
+	Channel   string
+	User      string
+	Reaction  string
+	MessageID string
+	Removed   bool
+	Matches   [][]string
+}
+
+// MessageRef is return reference to reacted message
+func (e *ChatReactionEvent) MessageRef() ChatMessageRef {
+	return ChatMessageRef{Channel: e.Channel, ID: e.MessageID}
+}
+
+// ChatActionEvent is button clicked or menu option selected by user.
+// ActionID and Value are those of MessageAction, or selected ActionOption for select menu
+type ChatActionEvent struct {
+	Adapter   string
+	Channel   string
+	User      string
+	ActionID  string
+	Value     string
+	MessageID string
+	ThreadID  string
+	Matches   [][]string
+}
+
+// MessageRef is return reference to message having the action, to update it
+func (e *ChatActionEvent) MessageRef() ChatMessageRef {
+	return ChatMessageRef{Channel: e.Channel, ID: e.MessageID}
+}
+
+// ReplyInThread is post text to thread of event message
+func ReplyInThread(adapter ChatAdapter, event *ChatMessageEvent, text string) error {
+	_, err := adapter.PostThreadMessage(event.Channel, event.ThreadRootID(), &ChatMessage{Text: text})
+	return err
+}
+
+// ReplyEphemeral is post text visible only to event user
+func ReplyEphemeral(adapter ChatAdapter, event *ChatMessageEvent, text string) error {
+	return adapter.PostEphemeralMessage(event.Channel, event.User, &ChatMessage{Text: text})
+}
+
+// ReplyDirect is post text to event user by direct message
+func ReplyDirect(adapter ChatAdapter, event *ChatMessageEvent, text string) error {
+	_, err := adapter.PostDirectMessage(event.User, &ChatMessage{Text: text})
+	return err
+}
+
+// WithDescription is set description shown by help command
+func WithDescription(description string) HandlerOption {
+	return func(info *HandlerInfo) {
+		info.Description = description
+	}
 }
@@ -0,0 +1,210 @@
+package shellbind
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/astronoka/glados"
+)
+
+// NewChatAdapter is create shell chatadapter implement reading stdin and writing stdout
+func NewChatAdapter(c glados.Context) glados.ChatAdapter {
+	return NewChatAdapterWithIO(c, os.Stdin, os.Stdout)
+}
+
+// NewChatAdapterWithIO is create shell chatadapter implement with given reader and writer
+func NewChatAdapterWithIO(c glados.Context, in io.Reader, out io.Writer) glados.ChatAdapter {
+	adapter := &shellChatAdapter{
+		context: c,
+		out:     out,
+		channel: c.Env("GLADOS_SHELL_CHANNEL", "shell"),
+		user:    c.Env("GLADOS_SHELL_USER", c.Env("USER", "shell")),
+	}
+	go adapter.readLines(in)
+	return adapter
+}
+
+var shellReactionPattern = regexp.MustCompile(`^([+-]):([a-z0-9_+-]+):\s+(\S+)$`)
+
+// shellClickCommand is prefix of line clicking action, taken before slash commands
+const shellClickCommand = "/click"
+
+var shellActionPattern = regexp.MustCompile(`^` + shellClickCommand + `\s+(\S+)\s+(\S+)(?:\s+(.+))?$`)
+
+type shellChatAdapter struct {
+	mu      sync.Mutex
+	context glados.Context
+	out     io.Writer
+	channel string
+	user    string
+	lastID  int
+}
+
+func (s *shellChatAdapter) readLines(in io.Reader) {
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		if reaction, ok := s.parseReaction(text); ok {
+			s.context.Dispatcher().DispatchReaction(s, reaction)
+			continue
+		}
+		if action, ok := s.parseAction(text); ok {
+			s.context.Dispatcher().DispatchAction(s, action)
+			continue
+		}
+		if strings.HasPrefix(text, "/") {
+			s.dispatchSlashCommand(text)
+			continue
+		}
+		s.context.Dispatcher().Dispatch(s, &glados.ChatMessageEvent{
+			Channel:   s.channel,
+			User:      s.user,
+			Text:      text,
+			MessageID: s.nextID(),
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		s.context.Logger().Warnln("shellbind: read input failed. " + err.Error())
+	}
+	s.context.Logger().Debugln("shellbind: input closed")
+}
+
+func (s *shellChatAdapter) PostTextMessage(channel, text string) (glados.ChatMessageRef, error) {
+	return s.PostMessage(channel, &glados.ChatMessage{Text: text})
+}
+
+func (s *shellChatAdapter) PostMessage(channel string, message *glados.ChatMessage) (glados.ChatMessageRef, error) {
+	ref := glados.ChatMessageRef{Channel: channel, ID: s.nextID()}
+	return ref, s.post(fmt.Sprintf("#%s %s", channel, ref.ID), message)
+}
+
+func (s *shellChatAdapter) PostThreadMessage(channel, threadID string, message *glados.ChatMessage) (glados.ChatMessageRef, error) {
+	ref := glados.ChatMessageRef{Channel: channel, ID: s.nextID()}
+	return ref, s.post(fmt.Sprintf("#%s %s > %s", channel, threadID, ref.ID), message)
+}
+
+func (s *shellChatAdapter) PostEphemeralMessage(channel, user string, message *glados.ChatMessage) error {
+	return s.post(fmt.Sprintf("#%s (only @%s)", channel, user), message)
+}
+
+func (s *shellChatAdapter) PostDirectMessage(user string, message *glados.ChatMessage) (glados.ChatMessageRef, error) {
+	ref := glados.ChatMessageRef{Channel: "@" + user, ID: s.nextID()}
+	return ref, s.post(fmt.Sprintf("@%s %s", user, ref.ID), message)
+}
+
+func (s *shellChatAdapter) UpdateMessage(ref glados.ChatMessageRef, message *glados.ChatMessage) error {
+	return s.post(fmt.Sprintf("#%s %s edited", ref.Channel, ref.ID), message)
+}
+
+func (s *shellChatAdapter) DeleteMessage(ref glados.ChatMessageRef) error {
+	return s.println(fmt.Sprintf("[#%s %s deleted]", ref.Channel, ref.ID))
+}
+
+func (s *shellChatAdapter) AddReaction(ref glados.ChatMessageRef, name string) error {
+	return s.println(fmt.Sprintf("[#%s %s] %s: +:%s:", ref.Channel, ref.ID, s.context.BotName(), strings.Trim(name, ":")))
+}
+
+func (s *shellChatAdapter) RemoveReaction(ref glados.ChatMessageRef, name string) error {
+	return s.println(fmt.Sprintf("[#%s %s] %s: -:%s:", ref.Channel, ref.ID, s.context.BotName(), strings.Trim(name, ":")))
+}
+
+// parseReaction is parse "+:name: <message id>" or "-:name: <message id>" line as reaction
+func (s *shellChatAdapter) parseReaction(text string) (*glados.ChatReactionEvent, bool) {
+	m := shellReactionPattern.FindStringSubmatch(text)
+	if m == nil {
+		return nil, false
+	}
+	return &glados.ChatReactionEvent{
+		Channel:   s.channel,
+		User:      s.user,
+		Reaction:  m[2],
+		MessageID: m[3],
+		Removed:   m[1] == "-",
+	}, true
+}
+
+// parseAction is parse "/click <action id> <message id> [value]" line as clicked button or selected menu
+func (s *shellChatAdapter) parseAction(text string) (*glados.ChatActionEvent, bool) {
+	m := shellActionPattern.FindStringSubmatch(text)
+	if m == nil {
+		return nil, false
+	}
+	return &glados.ChatActionEvent{
+		Channel:   s.channel,
+		User:      s.user,
+		ActionID:  m[1],
+		MessageID: m[2],
+		Value:     m[3],
+	}, true
+}
+
+// dispatchSlashCommand is dispatch "/<command> [text]" line as slash command
+func (s *shellChatAdapter) dispatchSlashCommand(text string) {
+	command := strings.Fields(text)[0]
+	event := &glados.ChatMessageEvent{
+		Channel: s.channel,
+		User:    s.user,
+		Text:    strings.TrimSpace(strings.TrimPrefix(text, command)),
+		Command: command,
+	}
+	if !s.context.Dispatcher().DispatchSlashCommand(s, event) {
+		s.PostEphemeralMessage(s.channel, s.user, &glados.ChatMessage{Text: "unknown command " + command})
+	}
+}
+
+func (s *shellChatAdapter) nextID() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastID++
+	return strconv.Itoa(s.lastID)
+}
+
+func (s *shellChatAdapter) post(destination string, message *glados.ChatMessage) error {
+	if message.IsPlainText() {
+		return s.println(fmt.Sprintf("[%s] %s: %s", destination, s.context.BotName(), message.PlainText()))
+	}
+	lines := []string{fmt.Sprintf("[%s] %s:", destination, s.context.BotName())}
+	for _, line := range strings.Split(message.PlainText(), "\n") {
+		lines = append(lines, "  "+line)
+	}
+	return s.println(strings.Join(lines, "\n"))
+}
+
+func (s *shellChatAdapter) Here(pattern string, handler glados.ChatBotMessageHandler, options ...glados.HandlerOption) {
+	s.context.Dispatcher().Here(pattern, handler, options...)
+}
+
+func (s *shellChatAdapter) Respond(pattern string, handler glados.ChatBotMessageHandler, options ...glados.HandlerOption) {
+	s.context.Dispatcher().Respond(pattern, handler, options...)
+}
+
+func (s *shellChatAdapter) Reaction(pattern string, handler glados.ChatReactionHandler, options ...glados.HandlerOption) {
+	s.context.Dispatcher().Reaction(pattern, handler, options...)
+}
+
+func (s *shellChatAdapter) Action(pattern string, handler glados.ChatActionHandler, options ...glados.HandlerOption) {
+	s.context.Dispatcher().Action(pattern, handler, options...)
+}
+
+func (s *shellChatAdapter) SlashCommand(name string, handler glados.ChatBotMessageHandler, options ...glados.HandlerOption) {
+	s.context.Dispatcher().SlashCommand(name, handler, options...)
+}
+
+func (s *shellChatAdapter) println(text string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err := fmt.Fprintln(s.out, text)
+	if err != nil {
+		s.context.Logger().Warnln("shellbind: write output failed. " + err.Error())
+	}
+	return err
+}
@@ -0,0 +1,165 @@
+package shellbind_test
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/astronoka/glados"
+	"github.com/astronoka/glados/chatadapter/shellbind"
+	"github.com/astronoka/glados/gladostest"
+)
+
+// newPipedAdapter is create shell chat adapter reading lines written to in, and sending printed lines to out
+func newPipedAdapter() (glados.ChatAdapter, *io.PipeWriter, <-chan string) {
+	os.Setenv("GLADOS_SHELL_USER", "alice")
+	defer os.Unsetenv("GLADOS_SHELL_USER")
+	inReader, in := io.Pipe()
+	outReader, outWriter := io.Pipe()
+	adapter := shellbind.NewChatAdapterWithIO(gladostest.NewContext(), inReader, outWriter)
+	out := make(chan string, 16)
+	go func() {
+		scanner := bufio.NewScanner(outReader)
+		for scanner.Scan() {
+			out <- scanner.Text()
+		}
+	}()
+	return adapter, in, out
+}
+
+// readLines is read n printed lines
+func readLines(t *testing.T, out <-chan string, n int) []string {
+	lines := []string{}
+	for len(lines) < n {
+		select {
+		case line := <-out:
+			lines = append(lines, line)
+		case <-time.After(time.Second):
+			t.Fatalf("printed %q, want %d lines", lines, n)
+		}
+	}
+	return lines
+}
+
+// assertInputs is write each input and assert printed lines.
+// input printing nothing is detected by lines printed for the next input
+func assertInputs(t *testing.T, in io.Writer, out <-chan string, tests []struct {
+	input string
+	want  []string
+}) {
+	for _, test := range tests {
+		if _, err := io.WriteString(in, test.input+"\n"); err != nil {
+			t.Fatal(err)
+		}
+		got := readLines(t, out, len(test.want))
+		if strings.Join(got, "\n") != strings.Join(test.want, "\n") {
+			t.Errorf("%q: printed %q, want %q", test.input, got, test.want)
+		}
+	}
+}
+
+func TestRespondPrefix(t *testing.T) {
+	adapter, in, out := newPipedAdapter()
+	defer in.Close()
+	adapter.Respond("ping", func(a glados.ChatAdapter, e *glados.ChatMessageEvent) {
+		a.PostTextMessage(e.Channel, "pong to "+e.User)
+	})
+
+	// incoming message takes message id too
+	assertInputs(t, in, out, []struct {
+		input string
+		want  []string
+	}{
+		{"ping", nil},
+		{"GLaDOS ping", []string{"[#shell 3] GLaDOS: pong to alice"}},
+		{"GLaDOSping", nil},
+		{"@glados: ping", []string{"[#shell 6] GLaDOS: pong to alice"}},
+		{"  glados, ping  ", []string{"[#shell 8] GLaDOS: pong to alice"}},
+	})
+}
+
+func TestClickAction(t *testing.T) {
+	adapter, in, out := newPipedAdapter()
+	defer in.Close()
+	adapter.Action("^approve$", func(a glados.ChatAdapter, e *glados.ChatActionEvent) {
+		a.PostTextMessage(e.Channel, strings.TrimSpace("approved "+e.MessageID+" "+e.Value+" by "+e.User))
+	})
+
+	assertInputs(t, in, out, []struct {
+		input string
+		want  []string
+	}{
+		{"/click approve 3 v1.2.0", []string{"[#shell 1] GLaDOS: approved 3 v1.2.0 by alice"}},
+		{"/click approve 4", []string{"[#shell 2] GLaDOS: approved 4  by alice"}},
+		{"/click approve 5 release v1.2.0", []string{"[#shell 3] GLaDOS: approved 5 release v1.2.0 by alice"}},
+		// lines without /click are plain messages, even if they look like action
+		{"!approve 3 v1.2.0", nil},
+		{"approve 3 v1.2.0", nil},
+		{"/click approve", []string{"[#shell (only @alice)] GLaDOS: unknown command /click"}},
+		{"/click approve 6", []string{"[#shell 6] GLaDOS: approved 6  by alice"}},
+	})
+}
+
+func TestOutputFormat(t *testing.T) {
+	adapter, in, out := newPipedAdapter()
+	defer in.Close()
+	ref := glados.ChatMessageRef{Channel: "dev", ID: "1"}
+	text := &glados.ChatMessage{Text: "hello"}
+
+	tests := []struct {
+		name string
+		post func() error
+		want []string
+	}{
+		{"text", func() error {
+			_, err := adapter.PostTextMessage("dev", "hello")
+			return err
+		}, []string{"[#dev 1] GLaDOS: hello"}},
+		{"thread", func() error {
+			_, err := adapter.PostThreadMessage("dev", "1", text)
+			return err
+		}, []string{"[#dev 1 > 2] GLaDOS: hello"}},
+		{"ephemeral", func() error {
+			return adapter.PostEphemeralMessage("dev", "bob", text)
+		}, []string{"[#dev (only @bob)] GLaDOS: hello"}},
+		{"direct", func() error {
+			_, err := adapter.PostDirectMessage("bob", text)
+			return err
+		}, []string{"[@bob 3] GLaDOS: hello"}},
+		{"rich", func() error {
+			_, err := adapter.PostMessage("dev", &glados.ChatMessage{
+				Title: "Release",
+				Text:  "v1.2.0\nready",
+				Actions: []glados.MessageAction{
+					{Type: glados.ActionButton, ActionID: "approve", Text: "Approve", Value: "v1.2.0"},
+				},
+			})
+			return err
+		}, []string{"[#dev 4] GLaDOS:", "  Release", "  v1.2.0", "  ready", "  [Approve: approve v1.2.0]"}},
+		{"update", func() error {
+			return adapter.UpdateMessage(ref, text)
+		}, []string{"[#dev 1 edited] GLaDOS: hello"}},
+		{"delete", func() error {
+			return adapter.DeleteMessage(ref)
+		}, []string{"[#dev 1 deleted]"}},
+		{"add reaction", func() error {
+			return adapter.AddReaction(ref, ":eyes:")
+		}, []string{"[#dev 1] GLaDOS: +:eyes:"}},
+		{"remove reaction", func() error {
+			return adapter.RemoveReaction(ref, "eyes")
+		}, []string{"[#dev 1] GLaDOS: -:eyes:"}},
+	}
+	for _, test := range tests {
+		if err := test.post(); err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		got := readLines(t, out, len(test.want))
+		if strings.Join(got, "\n") != strings.Join(test.want, "\n") {
+			t.Errorf("%s: printed %q, want %q", test.name, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package slackbind
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const defaultSlackAPIURL = "https://slack.com/api/"
+
+var apiClient = &http.Client{Timeout: 30 * time.Second}
+
+type apiResponse struct {
+	OK    bool   `json:"ok"`
+	Error string `json:"error"`
+}
+
+// callAPI is call slack web api not covered by slack client
+func (s *slackChatAdapter) callAPI(method string, values url.Values, response interface{}) error {
+	values.Set("token", s.token)
+	res, err := apiClient.PostForm(s.apiURL+method, values)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+	if res.StatusCode != http.StatusOK {
+		return errors.New(method + ": " + res.Status)
+	}
+	result := apiResponse{}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return err
+	}
+	if !result.OK {
+		return errors.New(method + ": " + result.Error)
+	}
+	if response == nil {
+		return nil
+	}
+	return json.Unmarshal(body, response)
+}
@@ -1,7 +1,9 @@
 package slackbind
 
 import (
-	"fmt"
+	"encoding/json"
+	"errors"
+	"net/url"
 	"regexp"
 	"strings"
 	"sync"
@@ -10,37 +12,47 @@ import (
 	"github.com/nlopes/slack"
 )
 
-// NewChatAdapter is create slack chatadapter implement
+// NewChatAdapter is create slack chatadapter implement receiving messages by RTM.
+// the interactivity and slash command request URLs are mounted on context router
 func NewChatAdapter(c glados.Context) glados.ChatAdapter {
-	slackClient := slack.New(c.Env("GLADOS_SLACK_BOT_UAER_TOKEN", ""))
-	rtm := slackClient.NewRTM()
-	adapter := &slackChatAdapter{
+	return NewNamedChatAdapter(c, "")
+}
+
+// NewNamedChatAdapter is create RTM slack chatadapter configured by GLADOS_SLACK_<NAME>_* env,
+// with request URLs mounted under /slack/<name>/. empty name is same as NewChatAdapter
+func NewNamedChatAdapter(c glados.Context, name string) glados.ChatAdapter {
+	config := loadConfig(c, name)
+	adapter := newSlackChatAdapter(c, config)
+	mountInteractions(c, adapter, config)
+	mountSlashCommands(c, adapter, config)
+	adapter.rtm = adapter.client.NewRTM()
+	go adapter.rtm.ManageConnection()
+	go adapter.handleRTMEvent()
+	return adapter
+}
+
+func newSlackChatAdapter(c glados.Context, config config) *slackChatAdapter {
+	return &slackChatAdapter{
 		users:    make(map[string]*slack.User),
 		channels: make(map[string]*slack.Channel),
 		context:  c,
-		client:   slackClient,
-		rtm:      rtm,
+		client:   slack.New(config.token),
+		token:    config.token,
+		apiURL:   config.apiURL,
 	}
-	go rtm.ManageConnection()
-	go adapter.handleRTMEvent()
-	return adapter
 }
 
 var slackUserIDPattern = regexp.MustCompile(`<@([a-zA-Z0-9_-]+)>`)
 
-type hereHandler struct {
-	regexp *regexp.Regexp
-	handle glados.ChatBotMessageHandler
-}
-
 type slackChatAdapter struct {
-	mu           sync.Mutex
-	users        map[string]*slack.User
-	channels     map[string]*slack.Channel
-	context      glados.Context
-	client       *slack.Client
-	rtm          *slack.RTM
-	hereHandlers []hereHandler
+	mu       sync.Mutex
+	users    map[string]*slack.User
+	channels map[string]*slack.Channel
+	context  glados.Context
+	client   *slack.Client
+	rtm      *slack.RTM
+	token    string
+	apiURL   string
 }
 
 func (s *slackChatAdapter) handleRTMEvent() {
@@ -63,6 +75,10 @@ func (s *slackChatAdapter) recieveEventLoop() bool {
 			// Ignore hello
 		case *slack.MessageEvent:
 			s.onMessageEvent(ev)
+		case *slack.ReactionAddedEvent:
+			s.onReactionEvent(ev.User, ev.Item.Channel, ev.Item.Timestamp, ev.Reaction, false)
+		case *slack.ReactionRemovedEvent:
+			s.onReactionEvent(ev.User, ev.Item.Channel, ev.Item.Timestamp, ev.Reaction, true)
 		case *slack.PresenceChangeEvent:
 			logger.Debugf("slackbind: Presence Change: %v\n", ev)
 		case *slack.LatencyReport:
@@ -83,54 +99,149 @@ func (s *slackChatAdapter) recieveEventLoop() bool {
 	return true
 }
 
-func (s *slackChatAdapter) PostTextMessage(channel, text string) {
-	params := slack.PostMessageParameters{
-		Username:  s.context.BotName(),
-		AsUser:    true,
-		LinkNames: 1,
+func (s *slackChatAdapter) Close() error {
+	if s.rtm == nil {
+		return nil
 	}
-	_, _, err := s.client.PostMessage(channel, text, params)
+	return s.rtm.Disconnect()
+}
+
+func (s *slackChatAdapter) PostTextMessage(channel, text string) (glados.ChatMessageRef, error) {
+	return s.PostMessage(channel, &glados.ChatMessage{Text: text})
+}
+
+func (s *slackChatAdapter) PostMessage(channel string, message *glados.ChatMessage) (glados.ChatMessageRef, error) {
+	return s.postMessage(s.messageValues(channel, message))
+}
+
+func (s *slackChatAdapter) PostThreadMessage(channel, threadID string, message *glados.ChatMessage) (glados.ChatMessageRef, error) {
+	values := s.messageValues(channel, message)
+	values.Set("thread_ts", threadID)
+	return s.postMessage(values)
+}
+
+func (s *slackChatAdapter) PostEphemeralMessage(channel, user string, message *glados.ChatMessage) error {
+	userID, err := s.getUserID(user)
 	if err != nil {
-		s.context.Logger().Warnln("slackbind: post text message error. " + err.Error())
+		return err
 	}
+	values := s.messageValues(channel, message)
+	values.Set("user", userID)
+	return s.callAPI("chat.postEphemeral", values, nil)
 }
 
-func (s *slackChatAdapter) PostMessage(channel string, message *glados.ChatMessage) {
-	attachment := slack.Attachment{
-		AuthorName:    message.Author.Name,
-		AuthorSubname: message.Author.Subname,
-		AuthorLink:    message.Author.Link,
-		AuthorIcon:    message.Author.IconURL,
-		Title:         message.Title,
-		TitleLink:     message.TitleLinkURL,
-		Text:          message.Text,
-		Color:         message.Color,
+func (s *slackChatAdapter) PostDirectMessage(user string, message *glados.ChatMessage) (glados.ChatMessageRef, error) {
+	userID, err := s.getUserID(user)
+	if err != nil {
+		return glados.ChatMessageRef{}, err
 	}
-	params := slack.PostMessageParameters{
-		Username:    s.context.BotName(),
-		AsUser:      true,
-		LinkNames:   1,
-		Attachments: []slack.Attachment{attachment},
+	_, _, channelID, err := s.client.OpenIMChannel(userID)
+	if err != nil {
+		return glados.ChatMessageRef{}, err
 	}
-	_, _, err := s.client.PostMessage(channel, "", params)
+	return s.PostMessage(channelID, message)
+}
+
+func (s *slackChatAdapter) UpdateMessage(ref glados.ChatMessageRef, message *glados.ChatMessage) error {
+	values := s.messageValues(ref.Channel, message)
+	values.Set("ts", ref.ID)
+	// clear blocks and attachments of previous message
+	for _, key := range []string{"blocks", "attachments"} {
+		if values.Get(key) == "" {
+			values.Set(key, "[]")
+		}
+	}
+	return s.callAPI("chat.update", values, nil)
+}
+
+func (s *slackChatAdapter) DeleteMessage(ref glados.ChatMessageRef) error {
+	values := url.Values{}
+	values.Set("channel", ref.Channel)
+	values.Set("ts", ref.ID)
+	return s.callAPI("chat.delete", values, nil)
+}
+
+func (s *slackChatAdapter) AddReaction(ref glados.ChatMessageRef, name string) error {
+	return s.callAPI("reactions.add", reactionValues(ref, name), nil)
+}
+
+func (s *slackChatAdapter) RemoveReaction(ref glados.ChatMessageRef, name string) error {
+	return s.callAPI("reactions.remove", reactionValues(ref, name), nil)
+}
+
+func reactionValues(ref glados.ChatMessageRef, name string) url.Values {
+	values := url.Values{}
+	values.Set("channel", ref.Channel)
+	values.Set("timestamp", ref.ID)
+	values.Set("name", strings.Trim(name, ":"))
+	return values
+}
+
+type postMessageResponse struct {
+	Channel   string `json:"channel"`
+	Timestamp string `json:"ts"`
+}
+
+func (s *slackChatAdapter) postMessage(values url.Values) (glados.ChatMessageRef, error) {
+	response := postMessageResponse{}
+	err := s.callAPI("chat.postMessage", values, &response)
 	if err != nil {
-		s.context.Logger().Warnln("slackbind: post message error. " + err.Error())
+		return glados.ChatMessageRef{}, err
 	}
+	return glados.ChatMessageRef{
+		Channel: response.Channel,
+		ID:      response.Timestamp,
+	}, nil
 }
 
-func (s *slackChatAdapter) Here(pattern string, handler glados.ChatBotMessageHandler) {
-	s.hereHandlers = append(s.hereHandlers, hereHandler{
-		regexp: regexp.MustCompile(pattern),
-		handle: handler,
-	})
+func (s *slackChatAdapter) messageValues(channel string, message *glados.ChatMessage) url.Values {
+	values := url.Values{}
+	values.Set("channel", channel)
+	values.Set("username", s.context.BotName())
+	values.Set("as_user", "true")
+	values.Set("link_names", "1")
+	if message.IsPlainText() {
+		values.Set("text", message.Text)
+		return values
+	}
+	values.Set("text", fallbackText(message))
+	key, content := richContent(message)
+	b, _ := json.Marshal(content)
+	values.Set(key, string(b))
+	return values
 }
 
-func (s *slackChatAdapter) Respond(pattern string, handler glados.ChatBotMessageHandler) {
-	respondRegexpPattern := fmt.Sprintf(`^(?:@?(?:%s|%s)[:,]?)\s+(?:%s)`,
-		s.context.BotName(), s.context.BotNameAlias(), pattern)
-	s.hereHandlers = append(s.hereHandlers, hereHandler{
-		regexp: regexp.MustCompile(respondRegexpPattern),
-		handle: handler,
+func (s *slackChatAdapter) Here(pattern string, handler glados.ChatBotMessageHandler, options ...glados.HandlerOption) {
+	s.context.Dispatcher().Here(pattern, handler, options...)
+}
+
+func (s *slackChatAdapter) Respond(pattern string, handler glados.ChatBotMessageHandler, options ...glados.HandlerOption) {
+	s.context.Dispatcher().Respond(pattern, handler, options...)
+}
+
+func (s *slackChatAdapter) Reaction(pattern string, handler glados.ChatReactionHandler, options ...glados.HandlerOption) {
+	s.context.Dispatcher().Reaction(pattern, handler, options...)
+}
+
+func (s *slackChatAdapter) Action(pattern string, handler glados.ChatActionHandler, options ...glados.HandlerOption) {
+	s.context.Dispatcher().Action(pattern, handler, options...)
+}
+
+func (s *slackChatAdapter) SlashCommand(name string, handler glados.ChatBotMessageHandler, options ...glados.HandlerOption) {
+	s.context.Dispatcher().SlashCommand(name, handler, options...)
+}
+
+// onReactionEvent is dispatch reaction to message. reaction to file is ignored
+func (s *slackChatAdapter) onReactionEvent(userID, channelID, timestamp, reaction string, removed bool) {
+	if channelID == "" || timestamp == "" {
+		return
+	}
+	s.context.Dispatcher().DispatchReaction(s, &glados.ChatReactionEvent{
+		Channel:   channelID,
+		User:      s.getUserName(userID),
+		Reaction:  reaction,
+		MessageID: timestamp,
+		Removed:   removed,
 	})
 }
 
@@ -138,23 +249,20 @@ func (s *slackChatAdapter) onMessageEvent(event *slack.MessageEvent) {
 	if isBotMessage(event) || event.Hidden {
 		return
 	}
-	text := s.convertSlackUserID2Name(event.Text)
-	for _, handler := range s.hereHandlers {
-		matches := handler.regexp.FindAllStringSubmatch(text, -1)
-		if len(matches) <= 0 {
-			continue
-		}
-		handler.handle(s, &glados.ChatMessageEvent{
-			Channel: s.getChannelName(event.Channel),
-			User:    s.getUserName(event.User),
-			Text:    text,
-			Matches: matches,
-		})
-	}
+	s.context.Dispatcher().Dispatch(s, &glados.ChatMessageEvent{
+		Channel:   s.getChannelName(event.Channel),
+		User:      s.getUserName(event.User),
+		Text:      s.convertSlackUserID2Name(event.Text),
+		MessageID: event.Timestamp,
+		ThreadID:  event.ThreadTimestamp,
+	})
 }
 
 func (s *slackChatAdapter) getChannelName(channelID string) string {
-	if channel, exist := s.channels[channelID]; exist {
+	s.mu.Lock()
+	channel, exist := s.channels[channelID]
+	s.mu.Unlock()
+	if exist {
 		return channel.Name
 	}
 	channel, err := s.client.GetChannelInfo(channelID)
@@ -162,12 +270,17 @@ func (s *slackChatAdapter) getChannelName(channelID string) string {
 		s.context.Logger().Warnln("slackbind: get channel info failed. " + err.Error())
 		return channelID
 	}
+	s.mu.Lock()
 	s.channels[channelID] = channel
+	s.mu.Unlock()
 	return channel.Name
 }
 
 func (s *slackChatAdapter) getUserName(userID string) string {
-	if user, exist := s.users[userID]; exist {
+	s.mu.Lock()
+	user, exist := s.users[userID]
+	s.mu.Unlock()
+	if exist {
 		return user.Name
 	}
 	user, err := s.client.GetUserInfo(userID)
@@ -175,10 +288,52 @@ func (s *slackChatAdapter) getUserName(userID string) string {
 		s.context.Logger().Warnln("slackbind: get user info failed. " + err.Error())
 		return userID
 	}
+	s.mu.Lock()
 	s.users[userID] = user
+	s.mu.Unlock()
 	return user.Name
 }
 
+// getUserID is return id of user name. user not cached is looked up by users list,
+// and user id given as name is accepted
+func (s *slackChatAdapter) getUserID(userName string) (string, error) {
+	userName = strings.TrimPrefix(userName, "@")
+	if userID, exist := s.cachedUserID(userName); exist {
+		return userID, nil
+	}
+	users, err := s.client.GetUsers()
+	if err != nil {
+		s.context.Logger().Warnln("slackbind: get users failed. " + err.Error())
+	}
+	s.mu.Lock()
+	for i := range users {
+		s.users[users[i].ID] = &users[i]
+	}
+	s.mu.Unlock()
+	if userID, exist := s.cachedUserID(userName); exist {
+		return userID, nil
+	}
+	user, err := s.client.GetUserInfo(userName)
+	if err != nil {
+		return "", errors.New("slackbind: user " + userName + " is not found. " + err.Error())
+	}
+	s.mu.Lock()
+	s.users[user.ID] = user
+	s.mu.Unlock()
+	return user.ID, nil
+}
+
+func (s *slackChatAdapter) cachedUserID(userName string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for userID, user := range s.users {
+		if user.Name == userName {
+			return userID, true
+		}
+	}
+	return "", false
+}
+
 func (s *slackChatAdapter) convertSlackUserID2Name(text string) string {
 	var oldNew []string
 	uniqMap := map[string]struct{}{}
@@ -0,0 +1,299 @@
+package slackbind
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/astronoka/glados"
+)
+
+// limits of block kit. longer content is split or dropped
+const (
+	maxBlocks          = 50
+	maxSectionText     = 3000
+	maxSectionFields   = 10
+	maxFieldText       = 2000
+	maxContextElements = 10
+	maxActionElements  = 25
+	maxSelectOptions   = 100
+)
+
+type block struct {
+	Type      string        `json:"type"`
+	Text      *textObject   `json:"text,omitempty"`
+	Fields    []textObject  `json:"fields,omitempty"`
+	Elements  []interface{} `json:"elements,omitempty"`
+	Accessory *imageElement `json:"accessory,omitempty"`
+	ImageURL  string        `json:"image_url,omitempty"`
+	AltText   string        `json:"alt_text,omitempty"`
+}
+
+type textObject struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type imageElement struct {
+	Type     string `json:"type"`
+	ImageURL string `json:"image_url"`
+	AltText  string `json:"alt_text"`
+}
+
+type actionElement struct {
+	Type        string         `json:"type"`
+	ActionID    string         `json:"action_id"`
+	Text        *textObject    `json:"text,omitempty"`
+	Value       string         `json:"value,omitempty"`
+	Style       string         `json:"style,omitempty"`
+	Placeholder *textObject    `json:"placeholder,omitempty"`
+	Options     []optionObject `json:"options,omitempty"`
+}
+
+type optionObject struct {
+	Text  textObject `json:"text"`
+	Value string     `json:"value"`
+}
+
+type blockAttachment struct {
+	Color    string  `json:"color,omitempty"`
+	Fallback string  `json:"fallback,omitempty"`
+	Blocks   []block `json:"blocks"`
+}
+
+func markdown(text string) textObject {
+	return textObject{Type: "mrkdwn", Text: text}
+}
+
+func plainTextObject(text string) *textObject {
+	return &textObject{Type: "plain_text", Text: text}
+}
+
+// escape is escape text not written in slack markup
+func escape(text string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(text)
+}
+
+func markupLink(url, text string) string {
+	if url == "" {
+		return escape(text)
+	}
+	return "<" + url + "|" + escape(text) + ">"
+}
+
+// renderBlocks is render message as block kit blocks
+func renderBlocks(message *glados.ChatMessage) []block {
+	blocks := []block{}
+	if message.Author.Name != "" {
+		elements := []interface{}{}
+		if message.Author.IconURL != "" {
+			elements = append(elements, imageElement{Type: "image", ImageURL: message.Author.IconURL, AltText: message.Author.Name})
+		}
+		author := "*" + markupLink(message.Author.Link, message.Author.Name) + "*"
+		if message.Author.Subname != "" {
+			author += " " + escape(message.Author.Subname)
+		}
+		blocks = append(blocks, block{Type: "context", Elements: append(elements, markdown(author))})
+	}
+	if message.Title != "" {
+		title := markdown("*" + markupLink(message.TitleLinkURL, message.Title) + "*")
+		blocks = append(blocks, block{Type: "section", Text: &title})
+	}
+	blocks = append(blocks, textBlocks(message.Text)...)
+	blocks = append(blocks, fieldBlocks(message.Fields)...)
+	if message.ImageURL != "" {
+		blocks = append(blocks, block{Type: "image", ImageURL: message.ImageURL, AltText: altText(message.Title)})
+	}
+	for _, section := range message.Sections {
+		blocks = append(blocks, block{Type: "divider"})
+		text := section.Text
+		if section.Title != "" {
+			text = strings.TrimSpace("*" + escape(section.Title) + "*\n" + text)
+		}
+		sectionBlocks := textBlocks(text)
+		if section.ImageURL != "" {
+			image := &imageElement{Type: "image", ImageURL: section.ImageURL, AltText: altText(section.Title)}
+			if len(sectionBlocks) > 0 {
+				sectionBlocks[0].Accessory = image
+			} else {
+				blocks = append(blocks, block{Type: "image", ImageURL: section.ImageURL, AltText: altText(section.Title)})
+			}
+		}
+		blocks = append(blocks, sectionBlocks...)
+		blocks = append(blocks, fieldBlocks(section.Fields)...)
+	}
+	blocks = append(blocks, actionBlocks(message.Actions)...)
+	context := append([]string{}, message.Context...)
+	if footer := footerText(message); footer != "" {
+		context = append(context, footer)
+	}
+	for len(context) > 0 {
+		n := len(context)
+		if n > maxContextElements {
+			n = maxContextElements
+		}
+		elements := []interface{}{}
+		for _, text := range context[:n] {
+			elements = append(elements, markdown(text))
+		}
+		blocks = append(blocks, block{Type: "context", Elements: elements})
+		context = context[n:]
+	}
+	if len(blocks) > maxBlocks {
+		blocks = blocks[:maxBlocks]
+	}
+	return blocks
+}
+
+// textBlocks is split text into sections within text limit
+func textBlocks(text string) []block {
+	blocks := []block{}
+	for _, chunk := range splitText(text, maxSectionText) {
+		t := markdown(chunk)
+		blocks = append(blocks, block{Type: "section", Text: &t})
+	}
+	return blocks
+}
+
+// fieldBlocks is render short fields as section fields, and long field as own section
+func fieldBlocks(fields []glados.MessageField) []block {
+	blocks := []block{}
+	var short []textObject
+	flush := func() {
+		if len(short) > 0 {
+			blocks = append(blocks, block{Type: "section", Fields: short})
+			short = nil
+		}
+	}
+	for _, field := range fields {
+		text := strings.TrimSpace("*" + escape(field.Title) + "*\n" + field.Value)
+		if !field.Short {
+			flush()
+			blocks = append(blocks, textBlocks(text)...)
+			continue
+		}
+		short = append(short, markdown(truncateText(text, maxFieldText)))
+		if len(short) >= maxSectionFields {
+			flush()
+		}
+	}
+	flush()
+	return blocks
+}
+
+// actionBlocks is render buttons and select menus as actions blocks
+func actionBlocks(actions []glados.MessageAction) []block {
+	blocks := []block{}
+	for len(actions) > 0 {
+		n := len(actions)
+		if n > maxActionElements {
+			n = maxActionElements
+		}
+		elements := []interface{}{}
+		for _, action := range actions[:n] {
+			elements = append(elements, actionElementOf(action))
+		}
+		blocks = append(blocks, block{Type: "actions", Elements: elements})
+		actions = actions[n:]
+	}
+	return blocks
+}
+
+func actionElementOf(action glados.MessageAction) actionElement {
+	if action.Type != glados.ActionSelect {
+		return actionElement{
+			Type:     "button",
+			ActionID: action.ActionID,
+			Text:     plainTextObject(action.Text),
+			Value:    action.Value,
+			Style:    action.Style,
+		}
+	}
+	options := []optionObject{}
+	for i, option := range action.Options {
+		if i >= maxSelectOptions {
+			break
+		}
+		options = append(options, optionObject{Text: *plainTextObject(option.Text), Value: option.Value})
+	}
+	return actionElement{
+		Type:        "static_select",
+		ActionID:    action.ActionID,
+		Placeholder: plainTextObject(action.Text),
+		Options:     options,
+	}
+}
+
+// footerText is footer followed by timestamp shown in local time of reader
+func footerText(message *glados.ChatMessage) string {
+	if message.Timestamp.IsZero() {
+		return message.Footer
+	}
+	date := "<!date^" + strconv.FormatInt(message.Timestamp.Unix(), 10) + "^{date_short_pretty} {time}|" +
+		message.Timestamp.UTC().Format("2006-01-02 15:04 UTC") + ">"
+	if message.Footer == "" {
+		return date
+	}
+	return message.Footer + " | " + date
+}
+
+// richContent is return blocks, or attachment of blocks for colored message
+// since block kit has no color bar
+func richContent(message *glados.ChatMessage) (string, interface{}) {
+	blocks := renderBlocks(message)
+	if message.Color == "" {
+		return "blocks", blocks
+	}
+	return "attachments", []blockAttachment{{
+		Color:    message.Color,
+		Fallback: fallbackText(message),
+		Blocks:   blocks,
+	}}
+}
+
+// fallbackText is text shown in notifications of rich message
+func fallbackText(message *glados.ChatMessage) string {
+	if message.Title != "" {
+		return message.Title
+	}
+	if message.Text != "" {
+		return message.Text
+	}
+	return message.PlainText()
+}
+
+func altText(text string) string {
+	if text == "" {
+		return "image"
+	}
+	return text
+}
+
+// splitText is split text into chunks of at most limit bytes, at line break if possible
+func splitText(text string, limit int) []string {
+	text = strings.TrimSpace(text)
+	chunks := []string{}
+	for len(text) > limit {
+		cut := strings.LastIndex(text[:limit], "\n")
+		if cut <= 0 {
+			cut = limit
+			for cut > 0 && !utf8.RuneStart(text[cut]) {
+				cut--
+			}
+		}
+		chunks = append(chunks, text[:cut])
+		text = strings.TrimSpace(text[cut:])
+	}
+	if text != "" {
+		chunks = append(chunks, text)
+	}
+	return chunks
+}
+
+func truncateText(text string, limit int) string {
+	chunks := splitText(text, limit)
+	if len(chunks) <= 0 {
+		return ""
+	}
+	return chunks[0]
+}
@@ -0,0 +1,82 @@
+package slackbind
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSplitText(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		limit int
+		want  []string
+	}{
+		{"empty", "", 10, []string{}},
+		{"blank", "  \n ", 10, []string{}},
+		{"short", "hello", 10, []string{"hello"}},
+		{"exact limit", "0123456789", 10, []string{"0123456789"}},
+		{"trimmed", "  hello \n", 10, []string{"hello"}},
+		{"at line break", "hello\nworld", 8, []string{"hello", "world"}},
+		{"at last line break", "ab\ncd\nefghij", 6, []string{"ab\ncd", "efghij"}},
+		{"no line break", "0123456789abc", 5, []string{"01234", "56789", "abc"}},
+		{"line break at head", "\n0123456789", 5, []string{"01234", "56789"}},
+		{"multibyte rune", "あいうえ", 7, []string{"あい", "うえ"}},
+		{"multibyte at limit", "あいう", 6, []string{"あい", "う"}},
+	}
+	for _, test := range tests {
+		got := splitText(test.text, test.limit)
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: splitText(%q, %d) = %q, want %q", test.name, test.text, test.limit, got, test.want)
+		}
+		for _, chunk := range got {
+			if len(chunk) > test.limit {
+				t.Errorf("%s: chunk %q exceeds limit %d", test.name, chunk, test.limit)
+			}
+		}
+	}
+}
+
+func TestTruncateText(t *testing.T) {
+	tests := []struct {
+		text  string
+		limit int
+		want  string
+	}{
+		{"", 10, ""},
+		{"hello", 10, "hello"},
+		{"hello\nworld", 8, "hello"},
+		{"あいう", 4, "あ"},
+	}
+	for _, test := range tests {
+		if got := truncateText(test.text, test.limit); got != test.want {
+			t.Errorf("truncateText(%q, %d) = %q, want %q", test.text, test.limit, got, test.want)
+		}
+	}
+}
+
+func TestTextBlocks(t *testing.T) {
+	long := strings.Repeat("a", maxSectionText) + "\n" + "b"
+	tests := []struct {
+		name string
+		text string
+		want int
+	}{
+		{"empty", "", 0},
+		{"short", "hello", 1},
+		{"over section limit", long, 2},
+	}
+	for _, test := range tests {
+		blocks := textBlocks(test.text)
+		if len(blocks) != test.want {
+			t.Errorf("%s: got %d blocks, want %d", test.name, len(blocks), test.want)
+			continue
+		}
+		for _, b := range blocks {
+			if b.Type != "section" || b.Text == nil || b.Text.Type != "mrkdwn" || len(b.Text.Text) > maxSectionText {
+				t.Errorf("%s: invalid block %+v", test.name, b)
+			}
+		}
+	}
+}
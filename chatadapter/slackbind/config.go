@@ -0,0 +1,39 @@
+package slackbind
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/astronoka/glados"
+)
+
+var envNameInvalidPattern = regexp.MustCompile(`[^A-Z0-9]+`)
+
+// config is settings of slack chat adapter read from env.
+// settings of named adapter are read from GLADOS_SLACK_<NAME>_* and its request URLs are mounted under /slack/<name>/,
+// so that several slack adapters work side by side
+type config struct {
+	token            string
+	signingSecret    string
+	apiURL           string
+	eventsPath       string
+	interactionsPath string
+	commandsPath     string
+}
+
+func loadConfig(c glados.Context, name string) config {
+	prefix := "GLADOS_SLACK_"
+	path := "/slack/"
+	if name != "" {
+		prefix += strings.Trim(envNameInvalidPattern.ReplaceAllString(strings.ToUpper(name), "_"), "_") + "_"
+		path += name + "/"
+	}
+	return config{
+		token:            c.Env(prefix+"BOT_UAER_TOKEN", ""),
+		signingSecret:    c.Env(prefix+"SIGNING_SECRET", ""),
+		apiURL:           c.Env(prefix+"API_URL", c.Env("GLADOS_SLACK_API_URL", defaultSlackAPIURL)),
+		eventsPath:       c.Env(prefix+"EVENTS_PATH", path+"events"),
+		interactionsPath: c.Env(prefix+"INTERACTIONS_PATH", path+"interactions"),
+		commandsPath:     c.Env(prefix+"COMMANDS_PATH", path+"commands"),
+	}
+}
@@ -0,0 +1,168 @@
+package slackbind
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/astronoka/glados"
+	"github.com/nlopes/slack"
+)
+
+// NewEventsAPIChatAdapter is create slack chatadapter implement receiving messages by Events API.
+// the request URL, the interactivity and slash command request URLs are mounted on context router
+func NewEventsAPIChatAdapter(c glados.Context) glados.ChatAdapter {
+	return NewNamedEventsAPIChatAdapter(c, "")
+}
+
+// NewNamedEventsAPIChatAdapter is create Events API slack chatadapter configured by GLADOS_SLACK_<NAME>_* env,
+// with request URLs mounted under /slack/<name>/. empty name is same as NewEventsAPIChatAdapter
+func NewNamedEventsAPIChatAdapter(c glados.Context, name string) glados.ChatAdapter {
+	config := loadConfig(c, name)
+	adapter := newSlackChatAdapter(c, config)
+	mountInteractions(c, adapter, config)
+	mountSlashCommands(c, adapter, config)
+	events := &eventsAPIHandler{
+		adapter:       adapter,
+		signingSecret: config.signingSecret,
+		seen:          map[string]time.Time{},
+	}
+	c.Router().POST(config.eventsPath, events.handle)
+	return adapter
+}
+
+const eventsAPIDedupeWindow = 10 * time.Minute
+
+type eventsAPIHandler struct {
+	mu            sync.Mutex
+	adapter       *slackChatAdapter
+	signingSecret string
+	seen          map[string]time.Time
+}
+
+type eventsAPIPayload struct {
+	Type      string          `json:"type"`
+	Challenge string          `json:"challenge"`
+	EventID   string          `json:"event_id"`
+	Event     json.RawMessage `json:"event"`
+}
+
+type eventsAPIMessage struct {
+	Type            string `json:"type"`
+	SubType         string `json:"subtype"`
+	Channel         string `json:"channel"`
+	User            string `json:"user"`
+	Text            string `json:"text"`
+	BotID           string `json:"bot_id"`
+	Hidden          bool   `json:"hidden"`
+	Timestamp       string `json:"ts"`
+	ThreadTimestamp string `json:"thread_ts"`
+}
+
+type eventsAPIReaction struct {
+	Type     string `json:"type"`
+	User     string `json:"user"`
+	Reaction string `json:"reaction"`
+	Item     struct {
+		Type      string `json:"type"`
+		Channel   string `json:"channel"`
+		Timestamp string `json:"ts"`
+	} `json:"item"`
+	EventTimestamp string `json:"event_ts"`
+}
+
+func (h *eventsAPIHandler) handle(rc glados.RequestContext) {
+	logger := h.adapter.context.Logger()
+	body, err := ioutil.ReadAll(rc.Request().Body)
+	if err != nil {
+		rc.JSON(http.StatusBadRequest, glados.H{"message": "slackbind: read body failed"})
+		return
+	}
+	if err := VerifyRequest(rc.Request().Header, h.signingSecret, body, time.Now()); err != nil {
+		logger.Warnln(err.Error())
+		rc.JSON(http.StatusUnauthorized, glados.H{"message": err.Error()})
+		return
+	}
+	payload := eventsAPIPayload{}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		rc.JSON(http.StatusBadRequest, glados.H{"message": "slackbind: invalid payload"})
+		return
+	}
+	switch payload.Type {
+	case "url_verification":
+		rc.JSON(http.StatusOK, glados.H{"challenge": payload.Challenge})
+		return
+	case "event_callback":
+		h.onEventCallback(payload)
+	default:
+		logger.Debugln("slackbind: Unsupported Events API payload: " + payload.Type)
+	}
+	rc.JSON(http.StatusOK, glados.H{"message": "ok"})
+}
+
+func (h *eventsAPIHandler) onEventCallback(payload eventsAPIPayload) {
+	message := eventsAPIMessage{}
+	if err := json.Unmarshal(payload.Event, &message); err != nil {
+		h.adapter.context.Logger().Warnln("slackbind: invalid event. " + err.Error())
+		return
+	}
+	h.adapter.context.Logger().Debugln("slackbind: Event Received: " + message.Type)
+	if message.Type == "reaction_added" || message.Type == "reaction_removed" {
+		h.onReaction(payload)
+		return
+	}
+	if message.Type != "message" && message.Type != "app_mention" {
+		return
+	}
+	// a mention is delivered as both message and app_mention, and slack retries deliveries
+	if !h.markSeen(message.Channel + ":" + message.Timestamp) {
+		return
+	}
+	event := &slack.MessageEvent{
+		Msg: slack.Msg{
+			Type:            message.Type,
+			SubType:         message.SubType,
+			Channel:         message.Channel,
+			User:            message.User,
+			Text:            message.Text,
+			BotID:           message.BotID,
+			Hidden:          message.Hidden,
+			Timestamp:       message.Timestamp,
+			ThreadTimestamp: message.ThreadTimestamp,
+		},
+	}
+	// reply to slack within 3 seconds, handlers run after response
+	go h.adapter.onMessageEvent(event)
+}
+
+func (h *eventsAPIHandler) onReaction(payload eventsAPIPayload) {
+	reaction := eventsAPIReaction{}
+	if err := json.Unmarshal(payload.Event, &reaction); err != nil {
+		h.adapter.context.Logger().Warnln("slackbind: invalid event. " + err.Error())
+		return
+	}
+	// slack retries deliveries with same event id
+	if payload.EventID != "" && !h.markSeen(payload.EventID) {
+		return
+	}
+	removed := reaction.Type == "reaction_removed"
+	go h.adapter.onReactionEvent(reaction.User, reaction.Item.Channel, reaction.Item.Timestamp, reaction.Reaction, removed)
+}
+
+func (h *eventsAPIHandler) markSeen(key string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	now := time.Now()
+	for k, t := range h.seen {
+		if now.Sub(t) > eventsAPIDedupeWindow {
+			delete(h.seen, k)
+		}
+	}
+	if _, exist := h.seen[key]; exist {
+		return false
+	}
+	h.seen[key] = now
+	return true
+}
@@ -0,0 +1,79 @@
+package slackbind_test
+
+import (
+	"bytes"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/astronoka/glados/chatadapter/slackbind"
+	"github.com/astronoka/glados/gladostest"
+)
+
+func newSignedRequest(path, secret string, timestamp time.Time, body string) *http.Request {
+	req, _ := http.NewRequest(http.MethodPost, path, bytes.NewReader([]byte(body)))
+	req.Header.Set("Content-Type", "application/json")
+	slackbind.SignRequest(req, secret, timestamp, []byte(body))
+	return req
+}
+
+func TestEventsAPIVerifiesSignature(t *testing.T) {
+	os.Setenv("GLADOS_SLACK_SIGNING_SECRET", "s3cret")
+	defer os.Unsetenv("GLADOS_SLACK_SIGNING_SECRET")
+	h := gladostest.New()
+	slackbind.NewEventsAPIChatAdapter(h.Context)
+
+	body := `{"type":"url_verification","challenge":"3eZbrw1aBm2rZgRNFdxV2595E9CY3gmdALWMmHkvFXO7tYXAYM8P"}`
+	tests := []struct {
+		name      string
+		secret    string
+		timestamp time.Time
+		status    int
+	}{
+		{"valid", "s3cret", time.Now(), http.StatusOK},
+		{"bad signature", "wrong", time.Now(), http.StatusUnauthorized},
+		{"stale timestamp", "s3cret", time.Now().Add(-10 * time.Minute), http.StatusUnauthorized},
+	}
+	for _, test := range tests {
+		res := h.Router.Do(newSignedRequest("/slack/events", test.secret, test.timestamp, body))
+		if res.Code != test.status {
+			t.Errorf("%s: status = %d, want %d. %s", test.name, res.Code, test.status, res.Body.String())
+			continue
+		}
+		if test.status == http.StatusOK && !strings.Contains(res.Body.String(), "3eZbrw1aBm2rZgRNFdxV2595E9CY3gmdALWMmHkvFXO7tYXAYM8P") {
+			t.Errorf("%s: challenge is not returned. %s", test.name, res.Body.String())
+		}
+	}
+}
+
+func TestNamedEventsAPIChatAdapters(t *testing.T) {
+	os.Setenv("GLADOS_SLACK_WORK_SIGNING_SECRET", "work-secret")
+	os.Setenv("GLADOS_SLACK_COMMUNITY_SIGNING_SECRET", "community-secret")
+	defer os.Unsetenv("GLADOS_SLACK_WORK_SIGNING_SECRET")
+	defer os.Unsetenv("GLADOS_SLACK_COMMUNITY_SIGNING_SECRET")
+	h := gladostest.New()
+	slackbind.NewNamedEventsAPIChatAdapter(h.Context, "work")
+	slackbind.NewNamedEventsAPIChatAdapter(h.Context, "community")
+
+	body := `{"type":"url_verification","challenge":"c"}`
+	tests := []struct {
+		path   string
+		secret string
+		status int
+	}{
+		{"/slack/work/events", "work-secret", http.StatusOK},
+		{"/slack/work/events", "community-secret", http.StatusUnauthorized},
+		{"/slack/community/events", "community-secret", http.StatusOK},
+		{"/slack/community/commands", "work-secret", http.StatusUnauthorized},
+		{"/slack/work/interactions", "community-secret", http.StatusUnauthorized},
+		{"/slack/events", "work-secret", http.StatusNotFound},
+	}
+	for _, test := range tests {
+		res := h.Router.Do(newSignedRequest(test.path, test.secret, time.Now(), body))
+		if res.Code != test.status {
+			t.Errorf("%s signed by %s: status = %d, want %d. %s", test.path, test.secret, res.Code, test.status, res.Body.String())
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package slackbind
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/astronoka/glados"
+)
+
+// mountInteractions is mount interactivity request URL receiving clicked buttons and selected menus
+func mountInteractions(c glados.Context, adapter *slackChatAdapter, config config) {
+	interactions := &interactionsHandler{
+		adapter:       adapter,
+		signingSecret: config.signingSecret,
+	}
+	c.Router().POST(config.interactionsPath, interactions.handle)
+}
+
+type interactionsHandler struct {
+	adapter       *slackChatAdapter
+	signingSecret string
+}
+
+type interactionPayload struct {
+	Type string `json:"type"`
+	User struct {
+		ID       string `json:"id"`
+		Username string `json:"username"`
+	} `json:"user"`
+	Channel struct {
+		ID string `json:"id"`
+	} `json:"channel"`
+	Container struct {
+		MessageTimestamp string `json:"message_ts"`
+		ChannelID        string `json:"channel_id"`
+	} `json:"container"`
+	Message struct {
+		Timestamp       string `json:"ts"`
+		ThreadTimestamp string `json:"thread_ts"`
+	} `json:"message"`
+	Actions []struct {
+		ActionID       string `json:"action_id"`
+		Value          string `json:"value"`
+		SelectedOption struct {
+			Value string `json:"value"`
+		} `json:"selected_option"`
+	} `json:"actions"`
+}
+
+func (h *interactionsHandler) handle(rc glados.RequestContext) {
+	logger := h.adapter.context.Logger()
+	body, err := ioutil.ReadAll(rc.Request().Body)
+	if err != nil {
+		rc.JSON(http.StatusBadRequest, glados.H{"message": "slackbind: read body failed"})
+		return
+	}
+	if err := VerifyRequest(rc.Request().Header, h.signingSecret, body, time.Now()); err != nil {
+		logger.Warnln(err.Error())
+		rc.JSON(http.StatusUnauthorized, glados.H{"message": err.Error()})
+		return
+	}
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		rc.JSON(http.StatusBadRequest, glados.H{"message": "slackbind: invalid payload"})
+		return
+	}
+	payload := interactionPayload{}
+	if err := json.Unmarshal([]byte(form.Get("payload")), &payload); err != nil {
+		rc.JSON(http.StatusBadRequest, glados.H{"message": "slackbind: invalid payload"})
+		return
+	}
+	logger.Debugln("slackbind: Interaction Received: " + payload.Type)
+	if payload.Type == "block_actions" {
+		// reply to slack within 3 seconds, handlers run after response
+		go h.onBlockActions(payload)
+	}
+	rc.JSON(http.StatusOK, glados.H{"message": "ok"})
+}
+
+func (h *interactionsHandler) onBlockActions(payload interactionPayload) {
+	channel := payload.Container.ChannelID
+	if channel == "" {
+		channel = payload.Channel.ID
+	}
+	messageID := payload.Container.MessageTimestamp
+	if messageID == "" {
+		messageID = payload.Message.Timestamp
+	}
+	user := payload.User.Username
+	if user == "" {
+		user = h.adapter.getUserName(payload.User.ID)
+	}
+	for _, action := range payload.Actions {
+		value := action.Value
+		if value == "" {
+			value = action.SelectedOption.Value
+		}
+		h.adapter.context.Dispatcher().DispatchAction(h.adapter, &glados.ChatActionEvent{
+			Channel:   channel,
+			User:      user,
+			ActionID:  action.ActionID,
+			Value:     value,
+			MessageID: messageID,
+			ThreadID:  payload.Message.ThreadTimestamp,
+		})
+	}
+}
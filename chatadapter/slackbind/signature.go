@@ -0,0 +1,51 @@
+package slackbind
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	signatureHeader = "X-Slack-Signature"
+	timestampHeader = "X-Slack-Request-Timestamp"
+	signatureMaxAge = 5 * time.Minute
+)
+
+// Sign is compute slack request signature of body at timestamp
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("v0:" + strconv.FormatInt(timestamp.Unix(), 10) + ":"))
+	mac.Write(body)
+	return "v0=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// SignRequest is set signature headers same as slack, for testing endpoints locally
+func SignRequest(req *http.Request, secret string, timestamp time.Time, body []byte) {
+	req.Header.Set(timestampHeader, strconv.FormatInt(timestamp.Unix(), 10))
+	req.Header.Set(signatureHeader, Sign(secret, timestamp, body))
+}
+
+// VerifyRequest is verify slack request signature headers against body
+func VerifyRequest(header http.Header, secret string, body []byte, now time.Time) error {
+	if secret == "" {
+		return errors.New("slackbind: signing secret is not configured")
+	}
+	unix, err := strconv.ParseInt(header.Get(timestampHeader), 10, 64)
+	if err != nil {
+		return errors.New("slackbind: invalid request timestamp")
+	}
+	timestamp := time.Unix(unix, 0)
+	if now.Sub(timestamp) > signatureMaxAge || timestamp.Sub(now) > signatureMaxAge {
+		return errors.New("slackbind: request timestamp is too old")
+	}
+	expected := Sign(secret, timestamp, body)
+	if !hmac.Equal([]byte(expected), []byte(header.Get(signatureHeader))) {
+		return errors.New("slackbind: signature mismatch")
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package slackbind
+
+import (
+	"bytes"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestVerifyRequest(t *testing.T) {
+	now := time.Unix(1531420618, 0)
+	body := []byte("token=xyzz0WbapA4vBCDEFasx0q6G&team_id=T1DC2JH3J&command=%2Fdeploy&text=production")
+	signed := func(secret string, timestamp time.Time) http.Header {
+		req, _ := http.NewRequest(http.MethodPost, "/slack/commands", bytes.NewReader(body))
+		SignRequest(req, secret, timestamp, body)
+		return req.Header
+	}
+	tests := []struct {
+		name   string
+		header http.Header
+		secret string
+		body   []byte
+		ok     bool
+	}{
+		{"valid", signed("s3cret", now), "s3cret", body, true},
+		{"valid within max age", signed("s3cret", now.Add(-signatureMaxAge+time.Second)), "s3cret", body, true},
+		{"bad secret", signed("wrong", now), "s3cret", body, false},
+		{"tampered body", signed("s3cret", now), "s3cret", []byte(string(body) + "!"), false},
+		{"stale timestamp", signed("s3cret", now.Add(-signatureMaxAge-time.Second)), "s3cret", body, false},
+		{"future timestamp", signed("s3cret", now.Add(signatureMaxAge+time.Second)), "s3cret", body, false},
+		{"no secret configured", signed("", now), "", body, false},
+		{"no headers", http.Header{}, "s3cret", body, false},
+		{"malformed timestamp", http.Header{
+			timestampHeader: {"yesterday"},
+			signatureHeader: {Sign("s3cret", now, body)},
+		}, "s3cret", body, false},
+	}
+	for _, test := range tests {
+		err := VerifyRequest(test.header, test.secret, test.body, now)
+		if (err == nil) != test.ok {
+			t.Errorf("%s: VerifyRequest() error = %v, want ok %v", test.name, err, test.ok)
+		}
+	}
+}
+
+func TestSignRequest(t *testing.T) {
+	now := time.Unix(1531420618, 0)
+	body := []byte("payload")
+	req, _ := http.NewRequest(http.MethodPost, "/slack/events", bytes.NewReader(body))
+	SignRequest(req, "s3cret", now, body)
+	if got := req.Header.Get(timestampHeader); got != strconv.FormatInt(now.Unix(), 10) {
+		t.Errorf("timestamp header = %q", got)
+	}
+	if got := req.Header.Get(signatureHeader); got != Sign("s3cret", now, body) || len(got) != len("v0=")+64 {
+		t.Errorf("signature header = %q", got)
+	}
+}
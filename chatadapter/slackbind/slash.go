@@ -0,0 +1,200 @@
+package slackbind
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/astronoka/glados"
+)
+
+// slashCommandReplyWindow is how long reply is waited to return it as response of request.
+// slack requires response within 3 seconds, and later replies are sent to response_url
+const slashCommandReplyWindow = 2500 * time.Millisecond
+
+// mountSlashCommands is mount slash command request URL
+func mountSlashCommands(c glados.Context, adapter *slackChatAdapter, config config) {
+	commands := &slashCommandsHandler{
+		adapter:       adapter,
+		signingSecret: config.signingSecret,
+	}
+	c.Router().POST(config.commandsPath, commands.handle)
+}
+
+// NewSlashCommandRequest is create slash command request signed same as slack, for testing endpoint locally.
+// form has command, text, user_id, user_name, channel_id and response_url
+func NewSlashCommandRequest(path, secret string, form url.Values) *http.Request {
+	body := []byte(form.Encode())
+	req, err := http.NewRequest(http.MethodPost, path, bytes.NewReader(body))
+	if err != nil {
+		panic("slackbind: " + err.Error())
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	SignRequest(req, secret, time.Now(), body)
+	return req
+}
+
+type slashCommandsHandler struct {
+	adapter       *slackChatAdapter
+	signingSecret string
+}
+
+func (h *slashCommandsHandler) handle(rc glados.RequestContext) {
+	logger := h.adapter.context.Logger()
+	body, err := ioutil.ReadAll(rc.Request().Body)
+	if err != nil {
+		rc.JSON(http.StatusBadRequest, glados.H{"message": "slackbind: read body failed"})
+		return
+	}
+	if err := VerifyRequest(rc.Request().Header, h.signingSecret, body, time.Now()); err != nil {
+		logger.Warnln(err.Error())
+		rc.JSON(http.StatusUnauthorized, glados.H{"message": err.Error()})
+		return
+	}
+	form, err := url.ParseQuery(string(body))
+	if err != nil || form.Get("command") == "" {
+		rc.JSON(http.StatusBadRequest, glados.H{"message": "slackbind: invalid payload"})
+		return
+	}
+	logger.Debugln("slackbind: Slash Command Received: " + form.Get("command"))
+	user := form.Get("user_name")
+	if user == "" {
+		user = h.adapter.getUserName(form.Get("user_id"))
+	}
+	event := &glados.ChatMessageEvent{
+		Channel: h.adapter.getChannelName(form.Get("channel_id")),
+		User:    user,
+		Text:    strings.TrimSpace(form.Get("text")),
+		Command: form.Get("command"),
+	}
+	responder := &slashCommandResponder{
+		slackChatAdapter: h.adapter,
+		channel:          event.Channel,
+		user:             event.User,
+		responseURL:      form.Get("response_url"),
+		immediate:        make(chan glados.H, 1),
+	}
+	found := false
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		found = h.adapter.context.Dispatcher().DispatchSlashCommand(responder, event)
+	}()
+
+	select {
+	case reply := <-responder.immediate:
+		rc.JSON(http.StatusOK, reply)
+		return
+	case <-done:
+		if !found {
+			rc.JSON(http.StatusOK, glados.H{
+				"response_type": "ephemeral",
+				"text":          "unknown command " + event.Command,
+			})
+			return
+		}
+	case <-time.After(slashCommandReplyWindow):
+	}
+	if responder.acknowledge() {
+		rc.JSON(http.StatusOK, glados.H{})
+		return
+	}
+	// handler replied just now
+	rc.JSON(http.StatusOK, <-responder.immediate)
+}
+
+// slashCommandResponder is chat adapter passed to slash command handler.
+// reply to the command channel or user is returned as response of request,
+// or sent to response_url once request is acknowledged
+type slashCommandResponder struct {
+	*slackChatAdapter
+	mu           sync.Mutex
+	channel      string
+	user         string
+	responseURL  string
+	immediate    chan glados.H
+	acknowledged bool
+}
+
+// UnwrapChatAdapter is return slack chat adapter received the command
+func (r *slashCommandResponder) UnwrapChatAdapter() glados.ChatAdapter {
+	return r.slackChatAdapter
+}
+
+func (r *slashCommandResponder) PostTextMessage(channel, text string) (glados.ChatMessageRef, error) {
+	return r.PostMessage(channel, &glados.ChatMessage{Text: text})
+}
+
+func (r *slashCommandResponder) PostMessage(channel string, message *glados.ChatMessage) (glados.ChatMessageRef, error) {
+	if channel != r.channel {
+		return r.slackChatAdapter.PostMessage(channel, message)
+	}
+	return glados.ChatMessageRef{Channel: channel}, r.reply(responseBody(message, "in_channel"))
+}
+
+func (r *slashCommandResponder) PostEphemeralMessage(channel, user string, message *glados.ChatMessage) error {
+	if channel != r.channel || strings.TrimPrefix(user, "@") != r.user {
+		return r.slackChatAdapter.PostEphemeralMessage(channel, user, message)
+	}
+	return r.reply(responseBody(message, "ephemeral"))
+}
+
+func (r *slashCommandResponder) reply(body glados.H) error {
+	r.mu.Lock()
+	if !r.acknowledged {
+		r.acknowledged = true
+		r.mu.Unlock()
+		r.immediate <- body
+		return nil
+	}
+	r.mu.Unlock()
+	return r.postResponseURL(body)
+}
+
+// acknowledge is mark request as responded without reply, and return false if reply is already taken
+func (r *slashCommandResponder) acknowledge() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.acknowledged {
+		return false
+	}
+	r.acknowledged = true
+	return true
+}
+
+func (r *slashCommandResponder) postResponseURL(body glados.H) error {
+	if r.responseURL == "" {
+		return errors.New("slackbind: response_url is not given")
+	}
+	b, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	res, err := apiClient.Post(r.responseURL, "application/json", bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return errors.New("slackbind: response_url: " + res.Status)
+	}
+	return nil
+}
+
+func responseBody(message *glados.ChatMessage, responseType string) glados.H {
+	body := glados.H{"response_type": responseType}
+	if message.IsPlainText() {
+		body["text"] = message.Text
+		return body
+	}
+	body["text"] = fallbackText(message)
+	key, content := richContent(message)
+	body[key] = content
+	return body
+}
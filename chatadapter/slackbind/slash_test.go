@@ -0,0 +1,112 @@
+package slackbind_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/astronoka/glados"
+	"github.com/astronoka/glados/chatadapter/slackbind"
+	"github.com/astronoka/glados/gladostest"
+)
+
+// newSlashCommandHarness is create harness with slash commands, and server receiving replies to response_url
+func newSlashCommandHarness() (*gladostest.Harness, *httptest.Server, chan string) {
+	os.Setenv("GLADOS_SLACK_SIGNING_SECRET", "s3cret")
+	delayed := make(chan string, 4)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		delayed <- string(body)
+	}))
+	h := gladostest.New()
+	adapter := slackbind.NewEventsAPIChatAdapter(h.Context)
+	adapter.SlashCommand("/deploy", func(a glados.ChatAdapter, e *glados.ChatMessageEvent) {
+		a.PostTextMessage(e.Channel, "deploying "+e.Text+" by "+e.User)
+	})
+	adapter.SlashCommand("/slow", func(a glados.ChatAdapter, e *glados.ChatMessageEvent) {
+		time.Sleep(3 * time.Second)
+		a.PostTextMessage(e.Channel, "deployed "+e.Text)
+	})
+	return h, server, delayed
+}
+
+func slashCommandForm(command, responseURL string) url.Values {
+	return url.Values{
+		"command":      {command},
+		"text":         {"production"},
+		"user_name":    {"alice"},
+		"channel_id":   {"C1"},
+		"response_url": {responseURL},
+	}
+}
+
+func TestSlashCommandVerifiesSignature(t *testing.T) {
+	h, server, _ := newSlashCommandHarness()
+	defer server.Close()
+	defer os.Unsetenv("GLADOS_SLACK_SIGNING_SECRET")
+
+	body := slashCommandForm("/deploy", "").Encode()
+	tests := []struct {
+		name      string
+		secret    string
+		timestamp time.Time
+		status    int
+	}{
+		{"valid", "s3cret", time.Now(), http.StatusOK},
+		{"bad signature", "wrong", time.Now(), http.StatusUnauthorized},
+		{"stale timestamp", "s3cret", time.Now().Add(-10 * time.Minute), http.StatusUnauthorized},
+	}
+	for _, test := range tests {
+		req := newSignedRequest("/slack/commands", test.secret, test.timestamp, body)
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		if res := h.Router.Do(req); res.Code != test.status {
+			t.Errorf("%s: status = %d, want %d. %s", test.name, res.Code, test.status, res.Body.String())
+		}
+	}
+}
+
+func TestSlashCommandReply(t *testing.T) {
+	h, server, delayed := newSlashCommandHarness()
+	defer server.Close()
+	defer os.Unsetenv("GLADOS_SLACK_SIGNING_SECRET")
+
+	tests := []struct {
+		name      string
+		command   string
+		immediate []string
+		delayed   string
+	}{
+		{"immediate reply", "/deploy", []string{"in_channel", "deploying production by alice"}, ""},
+		{"slow reply to response_url", "/slow", []string{"{}"}, "deployed production"},
+		{"unknown command", "/nope", []string{"ephemeral", "unknown command /nope"}, ""},
+	}
+	for _, test := range tests {
+		req := slackbind.NewSlashCommandRequest("/slack/commands", "s3cret", slashCommandForm(test.command, server.URL))
+		res := h.Router.Do(req)
+		if res.Code != http.StatusOK {
+			t.Errorf("%s: status = %d. %s", test.name, res.Code, res.Body.String())
+			continue
+		}
+		for _, want := range test.immediate {
+			if !strings.Contains(res.Body.String(), want) {
+				t.Errorf("%s: response %s does not contain %q", test.name, res.Body.String(), want)
+			}
+		}
+		if test.delayed == "" {
+			continue
+		}
+		select {
+		case body := <-delayed:
+			if !strings.Contains(body, test.delayed) {
+				t.Errorf("%s: response_url body %s does not contain %q", test.name, body, test.delayed)
+			}
+		case <-time.After(2 * time.Second):
+			t.Errorf("%s: reply is not sent to response_url", test.name)
+		}
+	}
+}
@@ -0,0 +1,326 @@
+package glados
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CommandHandler is chat bot command callback function
+type CommandHandler func(adapter ChatAdapter, message *ChatMessageEvent, args *CommandArgs)
+
+// Command is parsed command spec such as "deploy <service> [<version>] [--env=staging] [--force:bool]"
+//
+// positional argument is written as <name> or <name:type>, optional one is enclosed in [ ],
+// and the last one may be variadic as <name...>.
+// flag is written as --name, --name=default or --name:type=default. flags are always optional.
+// type is one of string (default), int, float, bool and duration.
+type Command struct {
+	Spec       string
+	Words      []string
+	positional []commandParam
+	flags      map[string]commandParam
+}
+
+type commandParam struct {
+	name         string
+	kind         string
+	optional     bool
+	variadic     bool
+	defaultValue string
+}
+
+var commandParamPattern = regexp.MustCompile(`^(\[)?(?:<([a-zA-Z0-9_-]+)(\.\.\.)?(?::([a-z]+))?>|--([a-zA-Z0-9_-]+)(?::([a-z]+))?(?:=(.*?))?)(\])?$`)
+
+var commandKinds = map[string]struct{}{
+	"string":   {},
+	"int":      {},
+	"float":    {},
+	"bool":     {},
+	"duration": {},
+}
+
+// ParseCommand is parse command spec
+func ParseCommand(spec string) (*Command, error) {
+	command := &Command{
+		Spec:  spec,
+		flags: map[string]commandParam{},
+	}
+	for _, token := range strings.Fields(spec) {
+		if !strings.HasPrefix(token, "<") && !strings.HasPrefix(token, "[") && !strings.HasPrefix(token, "--") {
+			if len(command.positional) > 0 || len(command.flags) > 0 {
+				return nil, fmt.Errorf("glados: command %q has word %q after arguments", spec, token)
+			}
+			command.Words = append(command.Words, token)
+			continue
+		}
+		m := commandParamPattern.FindStringSubmatch(token)
+		if m == nil || (m[1] == "") != (m[8] == "") {
+			return nil, fmt.Errorf("glados: command %q has invalid argument %q", spec, token)
+		}
+		param := commandParam{optional: m[1] != ""}
+		if m[2] != "" {
+			param.name, param.variadic, param.kind = m[2], m[3] != "", m[4]
+		} else {
+			param.name, param.kind, param.defaultValue = m[5], m[6], m[7]
+			if param.kind == "" && strings.Index(token, "=") < 0 {
+				param.kind = "bool"
+			}
+		}
+		if param.kind == "" {
+			param.kind = "string"
+		}
+		if _, exist := commandKinds[param.kind]; !exist {
+			return nil, fmt.Errorf("glados: command %q has unknown type %q", spec, param.kind)
+		}
+		if m[2] == "" {
+			dummy := &CommandArgs{values: map[string]interface{}{}}
+			if err := dummy.set(param, param.defaultValue); err != nil {
+				return nil, fmt.Errorf("glados: command %q has invalid default. %s", spec, err.Error())
+			}
+			command.flags[param.name] = param
+			continue
+		}
+		if n := len(command.positional); n > 0 {
+			last := command.positional[n-1]
+			if last.variadic {
+				return nil, fmt.Errorf("glados: command %q has argument after variadic one", spec)
+			}
+			if last.optional && !param.optional {
+				return nil, fmt.Errorf("glados: command %q has required argument after optional one", spec)
+			}
+		}
+		command.positional = append(command.positional, param)
+	}
+	if len(command.Words) <= 0 {
+		return nil, fmt.Errorf("glados: command %q has no name", spec)
+	}
+	return command, nil
+}
+
+// MustParseCommand is parse command spec or panic
+func MustParseCommand(spec string) *Command {
+	command, err := ParseCommand(spec)
+	if err != nil {
+		panic(err.Error())
+	}
+	return command
+}
+
+// Pattern is regexp pattern matched command name and capturing arguments text
+func (c *Command) Pattern() string {
+	words := make([]string, len(c.Words))
+	for i, word := range c.Words {
+		words[i] = regexp.QuoteMeta(word)
+	}
+	return `(?is)` + strings.Join(words, `\s+`) + `(?:\s+(.*))?$`
+}
+
+// Parse is parse arguments text along with command spec
+func (c *Command) Parse(text string) (*CommandArgs, error) {
+	tokens, err := SplitCommandArgs(text)
+	if err != nil {
+		return nil, err
+	}
+	args := &CommandArgs{
+		values: map[string]interface{}{},
+		given:  map[string]bool{},
+	}
+	for _, flag := range c.flags {
+		if err := args.set(flag, flag.defaultValue); err != nil {
+			return nil, err
+		}
+	}
+	var positional []string
+	for i := 0; i < len(tokens); i++ {
+		token := tokens[i]
+		if token == "--" {
+			positional = append(positional, tokens[i+1:]...)
+			break
+		}
+		if !strings.HasPrefix(token, "--") {
+			positional = append(positional, token)
+			continue
+		}
+		name, value := token[2:], ""
+		hasValue := false
+		if j := strings.Index(name, "="); j >= 0 {
+			name, value, hasValue = name[:j], name[j+1:], true
+		}
+		flag, exist := c.flags[name]
+		if !exist {
+			return nil, fmt.Errorf("unknown flag --%s", name)
+		}
+		if !hasValue {
+			if flag.kind == "bool" {
+				value = "true"
+			} else if i+1 < len(tokens) {
+				i++
+				value = tokens[i]
+			} else {
+				return nil, fmt.Errorf("flag --%s needs a value", name)
+			}
+		}
+		if err := args.set(flag, value); err != nil {
+			return nil, err
+		}
+		args.given[name] = true
+	}
+	rest := positional
+	for _, param := range c.positional {
+		if len(rest) <= 0 {
+			if !param.optional {
+				return nil, fmt.Errorf("argument <%s> is required", param.name)
+			}
+			args.set(param, "")
+			continue
+		}
+		if param.variadic {
+			args.values[param.name] = rest
+			args.given[param.name] = true
+			rest = nil
+			break
+		}
+		if err := args.set(param, rest[0]); err != nil {
+			return nil, err
+		}
+		args.given[param.name] = true
+		rest = rest[1:]
+	}
+	if len(rest) > 0 {
+		return nil, fmt.Errorf("too many arguments %q", strings.Join(rest, " "))
+	}
+	return args, nil
+}
+
+// SplitCommandArgs is split text into arguments honoring quotes and backslash escapes
+func SplitCommandArgs(text string) ([]string, error) {
+	var args []string
+	var current []rune
+	var quote rune
+	inArg, escaped := false, false
+	for _, r := range text {
+		switch {
+		case escaped:
+			current = append(current, r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped, inArg = true, true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				current = append(current, r)
+			}
+		case r == '"' || r == '\'':
+			quote, inArg = r, true
+		case r == '“':
+			// chat clients often replace quotes with smart quotes
+			quote, inArg = '”', true
+		case r == ' ' || r == '\t' || r == '\n' || r == '\r':
+			if inArg {
+				args = append(args, string(current))
+				current, inArg = nil, false
+			}
+		default:
+			current, inArg = append(current, r), true
+		}
+	}
+	if quote != 0 || escaped {
+		return nil, errors.New("unterminated quote")
+	}
+	if inArg {
+		args = append(args, string(current))
+	}
+	return args, nil
+}
+
+// CommandArgs is typed arguments parsed from command text
+type CommandArgs struct {
+	values map[string]interface{}
+	given  map[string]bool
+}
+
+func (a *CommandArgs) set(param commandParam, value string) error {
+	if value == "" {
+		a.values[param.name] = zeroCommandValue(param.kind)
+		return nil
+	}
+	var v interface{}
+	var err error
+	switch param.kind {
+	case "int":
+		v, err = strconv.Atoi(value)
+	case "float":
+		v, err = strconv.ParseFloat(value, 64)
+	case "bool":
+		v, err = strconv.ParseBool(value)
+	case "duration":
+		v, err = time.ParseDuration(value)
+	default:
+		v = value
+	}
+	if err != nil {
+		return fmt.Errorf("%s is not %s: %q", param.name, param.kind, value)
+	}
+	a.values[param.name] = v
+	return nil
+}
+
+func zeroCommandValue(kind string) interface{} {
+	switch kind {
+	case "int":
+		return 0
+	case "float":
+		return float64(0)
+	case "bool":
+		return false
+	case "duration":
+		return time.Duration(0)
+	}
+	return ""
+}
+
+// Has is return true if argument or flag is given in text
+func (a *CommandArgs) Has(name string) bool {
+	return a.given[name]
+}
+
+// String is return string argument
+func (a *CommandArgs) String(name string) string {
+	v, _ := a.values[name].(string)
+	return v
+}
+
+// Strings is return variadic argument
+func (a *CommandArgs) Strings(name string) []string {
+	v, _ := a.values[name].([]string)
+	return v
+}
+
+// Int is return int argument
+func (a *CommandArgs) Int(name string) int {
+	v, _ := a.values[name].(int)
+	return v
+}
+
+// Float is return float argument
+func (a *CommandArgs) Float(name string) float64 {
+	v, _ := a.values[name].(float64)
+	return v
+}
+
+// Bool is return bool argument
+func (a *CommandArgs) Bool(name string) bool {
+	v, _ := a.values[name].(bool)
+	return v
+}
+
+// Duration is return duration argument
+func (a *CommandArgs) Duration(name string) time.Duration {
+	v, _ := a.values[name].(time.Duration)
+	return v
+}
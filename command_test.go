@@ -0,0 +1,163 @@
+package glados
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestSplitCommandArgs(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"", nil},
+		{"  ", nil},
+		{"a b  c", []string{"a", "b", "c"}},
+		{"a\tb\nc", []string{"a", "b", "c"}},
+		{`"hello world" x`, []string{"hello world", "x"}},
+		{`'it''s'`, []string{"its"}},
+		{`say "a 'b' c"`, []string{"say", "a 'b' c"}},
+		{`'a "b" \c'`, []string{`a "b" \c`}},
+		{`a\ b`, []string{"a b"}},
+		{`"a \"b\""`, []string{`a "b"`}},
+		{`""`, []string{""}},
+		{`x "" y`, []string{"x", "", "y"}},
+		{`--msg="fix bug"`, []string{"--msg=fix bug"}},
+		{"“smart quotes” ok", []string{"smart quotes", "ok"}},
+	}
+	for _, test := range tests {
+		got, err := SplitCommandArgs(test.text)
+		if err != nil {
+			t.Errorf("SplitCommandArgs(%q) failed. %s", test.text, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("SplitCommandArgs(%q) = %q, want %q", test.text, got, test.want)
+		}
+	}
+	for _, text := range []string{`"open`, `'open`, `trailing\`, "“open"} {
+		if _, err := SplitCommandArgs(text); err == nil {
+			t.Errorf("SplitCommandArgs(%q) must fail", text)
+		}
+	}
+}
+
+func TestParseCommandError(t *testing.T) {
+	specs := []string{
+		"",
+		"<service>",
+		"deploy <service> now",
+		"deploy <service:uuid>",
+		"deploy [<service>",
+		"deploy <service>]",
+		"deploy [<env>] <service>",
+		"deploy <services...> <env>",
+		"deploy --count:int=many",
+		"deploy --wait:duration=soon",
+		"deploy <>",
+	}
+	for _, spec := range specs {
+		if _, err := ParseCommand(spec); err == nil {
+			t.Errorf("ParseCommand(%q) must fail", spec)
+		}
+	}
+}
+
+func TestCommandPattern(t *testing.T) {
+	pattern := regexp.MustCompile(MustParseCommand("github rule add <destination>").Pattern())
+	tests := []struct {
+		text string
+		args string
+		ok   bool
+	}{
+		{"github rule add dev --event=push", "dev --event=push", true},
+		{"GitHub  Rule\tadd dev", "dev", true},
+		{"github rule add", "", true},
+		{"github rule adder dev", "", false},
+		{"github rules add dev", "", false},
+	}
+	for _, test := range tests {
+		m := pattern.FindStringSubmatch(test.text)
+		if (m != nil) != test.ok {
+			t.Errorf("%q matched = %v, want %v", test.text, m != nil, test.ok)
+			continue
+		}
+		if m != nil && m[1] != test.args {
+			t.Errorf("%q args = %q, want %q", test.text, m[1], test.args)
+		}
+	}
+}
+
+func TestCommandParse(t *testing.T) {
+	command := MustParseCommand("deploy <service> [<version>] [--env=staging] [--force] [--replicas:int=2] [--timeout:duration=30s] [--ratio:float]")
+	tests := []struct {
+		text     string
+		service  string
+		version  string
+		env      string
+		force    bool
+		replicas int
+		timeout  time.Duration
+		ratio    float64
+		given    []string
+	}{
+		{"api", "api", "", "staging", false, 2, 30 * time.Second, 0, []string{"service"}},
+		{"api v1.2.0", "api", "v1.2.0", "staging", false, 2, 30 * time.Second, 0, []string{"service", "version"}},
+		{"--env=production api", "api", "", "production", false, 2, 30 * time.Second, 0, []string{"service", "env"}},
+		{"api --env production --force", "api", "", "production", true, 2, 30 * time.Second, 0, []string{"service", "env", "force"}},
+		{"api --force=false --replicas=5 --timeout=1m --ratio 0.5", "api", "", "staging", false, 5, time.Minute, 0.5, []string{"service", "force", "replicas", "timeout", "ratio"}},
+		{`"my api" -- --v2`, "my api", "--v2", "staging", false, 2, 30 * time.Second, 0, []string{"service", "version"}},
+		{"api --env=", "api", "", "", false, 2, 30 * time.Second, 0, []string{"service", "env"}},
+	}
+	for _, test := range tests {
+		args, err := command.Parse(test.text)
+		if err != nil {
+			t.Errorf("Parse(%q) failed. %s", test.text, err)
+			continue
+		}
+		if args.String("service") != test.service || args.String("version") != test.version ||
+			args.String("env") != test.env || args.Bool("force") != test.force ||
+			args.Int("replicas") != test.replicas || args.Duration("timeout") != test.timeout ||
+			args.Float("ratio") != test.ratio {
+			t.Errorf("Parse(%q) = %+v", test.text, args.values)
+		}
+		for _, name := range test.given {
+			if !args.Has(name) {
+				t.Errorf("Parse(%q) does not have %s", test.text, name)
+			}
+		}
+		if len(args.given) != len(test.given) {
+			t.Errorf("Parse(%q) given = %v, want %v", test.text, args.given, test.given)
+		}
+	}
+}
+
+func TestCommandParseVariadic(t *testing.T) {
+	command := MustParseCommand("notify <channel> <words...>")
+	args, err := command.Parse(`dev hello "big world"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"hello", "big world"}; !reflect.DeepEqual(args.Strings("words"), want) {
+		t.Errorf("words = %q, want %q", args.Strings("words"), want)
+	}
+}
+
+func TestCommandParseError(t *testing.T) {
+	command := MustParseCommand("deploy <service> [--replicas:int=2] [--env=]")
+	texts := []string{
+		"",
+		"api extra",
+		"api --unknown",
+		"api --replicas=two",
+		"api --env",
+		`api "open`,
+	}
+	for _, text := range texts {
+		if _, err := command.Parse(text); err == nil {
+			t.Errorf("Parse(%q) must fail", text)
+		}
+	}
+}
@@ -13,10 +13,17 @@ type Context interface {
 	SetStorage(Storage)
 	SetRouter(Router)
 	SetChatAdapter(ChatAdapter)
+	AddChatAdapter(string, ChatAdapter)
 	Logger() Logger
 	Storage() Storage
 	Router() Router
 	ChatAdapter() ChatAdapter
+	NamedChatAdapter(string) ChatAdapter
+	ChatAdapterNames() []string
+	ChatAdapterName(ChatAdapter) string
+	Dispatcher() *Dispatcher
+	Scheduler() *Scheduler
+	Audit() *Audit
 	ListenPort() string
 	Env(string, string) string
 }
@@ -29,7 +36,12 @@ type contextImpl struct {
 	logger       Logger
 	storage      Storage
 	router       Router
-	chatadapter  ChatAdapter
+	chatadapters map[string]ChatAdapter
+	adapterNames []string
+	defaultName  string
+	dispatcher   *Dispatcher
+	scheduler    *Scheduler
+	audit        *Audit
 }
 
 func (c *contextImpl) BotName() string {
@@ -54,8 +66,28 @@ func (c *contextImpl) SetRouter(r Router) {
 	c.router = r
 }
 
+// SetChatAdapter is set default chat adapter named DefaultChatAdapterName
 func (c *contextImpl) SetChatAdapter(a ChatAdapter) {
-	c.chatadapter = a
+	c.AddChatAdapter(DefaultChatAdapterName, a)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.defaultName = DefaultChatAdapterName
+}
+
+// AddChatAdapter is register chat adapter by name. adapter added first is default unless SetChatAdapter is called
+func (c *contextImpl) AddChatAdapter(name string, a ChatAdapter) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.chatadapters == nil {
+		c.chatadapters = map[string]ChatAdapter{}
+	}
+	if _, exist := c.chatadapters[name]; !exist {
+		c.adapterNames = append(c.adapterNames, name)
+	}
+	c.chatadapters[name] = a
+	if c.defaultName == "" {
+		c.defaultName = name
+	}
 }
 
 func (c *contextImpl) Logger() Logger {
@@ -72,8 +104,63 @@ func (c *contextImpl) Router() Router {
 	return c.router
 }
 
+// ChatAdapter is return default chat adapter running outgoing middlewares of dispatcher
 func (c *contextImpl) ChatAdapter() ChatAdapter {
-	return c.chatadapter
+	return c.NamedChatAdapter("")
+}
+
+// NamedChatAdapter is return chat adapter of name running outgoing middlewares of dispatcher.
+// empty name is default adapter, and nil is returned if no adapter has the name
+func (c *contextImpl) NamedChatAdapter(name string) ChatAdapter {
+	c.mu.Lock()
+	if name == "" {
+		name = c.defaultName
+	}
+	adapter := c.chatadapters[name]
+	c.mu.Unlock()
+	return c.dispatcher.Adapter(adapter)
+}
+
+// ChatAdapterNames is return names of chat adapters in registered order
+func (c *contextImpl) ChatAdapterNames() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	names := make([]string, len(c.adapterNames))
+	copy(names, c.adapterNames)
+	return names
+}
+
+// ChatAdapterName is return name of registered chat adapter, or adapter wrapped by it.
+// empty string is returned if adapter is not registered
+func (c *contextImpl) ChatAdapterName(a ChatAdapter) string {
+	for a != nil {
+		c.mu.Lock()
+		for _, name := range c.adapterNames {
+			if c.chatadapters[name] == a {
+				c.mu.Unlock()
+				return name
+			}
+		}
+		c.mu.Unlock()
+		wrapper, ok := a.(ChatAdapterWrapper)
+		if !ok {
+			break
+		}
+		a = wrapper.UnwrapChatAdapter()
+	}
+	return ""
+}
+
+func (c *contextImpl) Dispatcher() *Dispatcher {
+	return c.dispatcher
+}
+
+func (c *contextImpl) Scheduler() *Scheduler {
+	return c.scheduler
+}
+
+func (c *contextImpl) Audit() *Audit {
+	return c.audit
 }
 
 func (c *contextImpl) ListenPort() string {
@@ -87,11 +174,20 @@ func (c *contextImpl) Env(key, valueIfNotFound string) string {
 // BuildDefaultContextFromEnv is create default contextImpl instance
 func BuildDefaultContextFromEnv() Context {
 	botName := env("BOT_NAME", "GLaDOS")
-	return &contextImpl{
+	return NewContext(botName, env("BOT_NAME_ALIAS", botName), env("PORT", "7000"))
+}
+
+// NewContext is create contextImpl instance
+func NewContext(botName, botNameAlias, listenPort string) Context {
+	c := &contextImpl{
 		botName:      botName,
-		botNameAlias: env("BOT_NAME_ALIAS", botName),
-		listenPort:   env("PORT", "7000"),
+		botNameAlias: botNameAlias,
+		listenPort:   listenPort,
 	}
+	c.dispatcher = NewDispatcher(c)
+	c.scheduler = NewScheduler(c)
+	c.audit = NewAudit(c)
+	return c
 }
 
 func env(key, valueIfNotFound string) string {
@@ -0,0 +1,94 @@
+package glados
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCronScheduleNext(t *testing.T) {
+	at := func(s string) time.Time {
+		v, err := time.Parse("2006-01-02 15:04", s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+	tests := []struct {
+		spec string
+		from string
+		want string
+	}{
+		{"* * * * *", "2018-06-01 10:07", "2018-06-01 10:08"},
+		{"*/15 * * * *", "2018-06-01 10:07", "2018-06-01 10:15"},
+		{"*/15 * * * *", "2018-06-01 10:45", "2018-06-01 11:00"},
+		{"5/20 * * * *", "2018-06-01 10:26", "2018-06-01 10:45"},
+		{"5-10/2 * * * *", "2018-06-01 10:11", "2018-06-01 11:05"},
+		{"0,30 9-10 * * *", "2018-06-01 10:30", "2018-06-02 09:00"},
+		// 2018-06-01 is friday
+		{"0 9 * * 1-5", "2018-06-01 10:00", "2018-06-04 09:00"},
+		{"0 9 * * mon-fri", "2018-06-01 08:00", "2018-06-01 09:00"},
+		{"0 0 * * 7", "2018-06-02 12:00", "2018-06-03 00:00"},
+		{"0 0 * * sun", "2018-06-02 12:00", "2018-06-03 00:00"},
+		// day of month or day of week when both are restricted
+		{"0 0 13 * 5", "2018-06-01 01:00", "2018-06-08 00:00"},
+		{"0 0 13 * 5", "2018-06-09 01:00", "2018-06-13 00:00"},
+		{"0 0 31 * *", "2018-04-01 00:00", "2018-05-31 00:00"},
+		{"0 0 1 jan,jul *", "2018-06-01 00:00", "2018-07-01 00:00"},
+		{"@monthly", "2018-12-15 00:00", "2019-01-01 00:00"},
+		{"@weekly", "2018-06-01 00:00", "2018-06-03 00:00"},
+		{"0 12 29 2 *", "2018-03-01 00:00", "2020-02-29 12:00"},
+	}
+	for _, test := range tests {
+		schedule, err := ParseCron(test.spec, time.UTC)
+		if err != nil {
+			t.Errorf("ParseCron(%q) failed. %s", test.spec, err)
+			continue
+		}
+		if got := schedule.Next(at(test.from)); !got.Equal(at(test.want)) {
+			t.Errorf("%q.Next(%s) = %s, want %s", test.spec, test.from, got.Format("2006-01-02 15:04"), test.want)
+		}
+	}
+}
+
+func TestCronScheduleNextInLocation(t *testing.T) {
+	tokyo := time.FixedZone("JST", 9*60*60)
+	schedule, err := ParseCron("0 9 * * *", tokyo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	from := time.Date(2018, 6, 1, 0, 30, 0, 0, time.UTC)
+	want := time.Date(2018, 6, 2, 0, 0, 0, 0, time.UTC)
+	if got := schedule.Next(from); !got.Equal(want) {
+		t.Errorf("Next(%s) = %s, want %s", from, got, want)
+	}
+}
+
+func TestParseCronError(t *testing.T) {
+	specs := []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"*/x * * * *",
+		"5-1 * * * *",
+		"0 0 * * mon-xyz",
+		"@every",
+	}
+	for _, spec := range specs {
+		if _, err := ParseCron(spec, time.UTC); err == nil {
+			t.Errorf("ParseCron(%q) must fail", spec)
+		}
+	}
+}
+
+func TestIntervalScheduleNext(t *testing.T) {
+	from := time.Date(2018, 6, 1, 10, 0, 0, 0, time.UTC)
+	if got := IntervalSchedule(90 * time.Second).Next(from); !got.Equal(from.Add(90 * time.Second)) {
+		t.Errorf("Next = %s", got)
+	}
+}
@@ -0,0 +1,342 @@
+package glados
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	dialogNamespace = "glados.dialogs"
+	// dialogIndexKey is key of waiting dialog keys, scanned to expire dialogs
+	dialogIndexKey = "index"
+	// dialogExpiryJob is name of scheduled job expiring dialogs
+	dialogExpiryJob = "glados.dialogs.expire"
+	// dialogExpiryInterval is how often dialogs are checked for expiry
+	dialogExpiryInterval = time.Minute
+	// DefaultDialogTimeout is how long answer is waited unless step has WithTimeout
+	DefaultDialogTimeout = 5 * time.Minute
+)
+
+// dialogCancelPattern is answer canceling dialog
+var dialogCancelPattern = regexp.MustCompile(`(?i)^\s*cancel\s*$`)
+
+// Dialog is conversation with user waiting for answer to question of Step.
+// it is saved in storage, so that dialog continues after restart
+type Dialog struct {
+	Name     string            `json:"name"`
+	Step     string            `json:"step"`
+	Adapter  string            `json:"adapter,omitempty"`
+	Channel  string            `json:"channel"`
+	User     string            `json:"user"`
+	ThreadID string            `json:"thread_id,omitempty"`
+	Question string            `json:"question"`
+	Values   map[string]string `json:"values"`
+	Expires  time.Time         `json:"expires"`
+}
+
+// DialogHandler is callback function called with answer of user.
+// dialog is finished unless handler asks next question by Dispatcher.Ask
+type DialogHandler func(adapter ChatAdapter, answer *ChatMessageEvent, dialog *Dialog)
+
+// DialogStepOption is optional setting of dialog step
+type DialogStepOption func(*dialogStep)
+
+// WithValidator is validate answer before handler is called.
+// error is replied and the question is asked again
+func WithValidator(validate func(answer string) error) DialogStepOption {
+	return func(step *dialogStep) {
+		step.validate = validate
+	}
+}
+
+// WithTimeout is set how long answer is waited
+func WithTimeout(timeout time.Duration) DialogStepOption {
+	return func(step *dialogStep) {
+		step.timeout = timeout
+	}
+}
+
+type dialogStep struct {
+	handle   DialogHandler
+	validate func(answer string) error
+	timeout  time.Duration
+}
+
+// dialogs is dialog steps registry and lock of dialog state in storage.
+// expiry job is scheduled once the first dialog is saved, so that bot without dialogs does not run it
+type dialogs struct {
+	mu     sync.Mutex
+	steps  map[string]dialogStep
+	expiry sync.Once
+}
+
+func dialogStepKey(name, step string) string {
+	return name + "/" + step
+}
+
+func dialogKey(adapter, channel, threadID, user string) string {
+	return adapter + ":" + channel + ":" + threadID + ":" + user
+}
+
+// DialogStep is register handler called with answer to question of step in dialog
+func (d *Dispatcher) DialogStep(name, step string, handler DialogHandler, options ...DialogStepOption) {
+	s := dialogStep{handle: handler, timeout: DefaultDialogTimeout}
+	for _, option := range options {
+		option(&s)
+	}
+	d.dialogs.mu.Lock()
+	defer d.dialogs.mu.Unlock()
+	if d.dialogs.steps == nil {
+		d.dialogs.steps = map[string]dialogStep{}
+	}
+	d.dialogs.steps[dialogStepKey(name, step)] = s
+}
+
+// StartDialog is start dialog with user of event, and ask question of step.
+// answer is waited in the channel, or in the thread if event is in thread
+func (d *Dispatcher) StartDialog(adapter ChatAdapter, event *ChatMessageEvent, name, step, question string) error {
+	return d.Ask(adapter, &Dialog{
+		Name:     name,
+		Adapter:  event.Adapter,
+		Channel:  event.Channel,
+		User:     event.User,
+		ThreadID: event.ThreadID,
+		Values:   map[string]string{},
+	}, step, question)
+}
+
+// Ask is post question of step and wait for next answer of dialog user
+func (d *Dispatcher) Ask(adapter ChatAdapter, dialog *Dialog, step, question string) error {
+	d.dialogs.mu.Lock()
+	s, exist := d.dialogs.steps[dialogStepKey(dialog.Name, step)]
+	d.dialogs.mu.Unlock()
+	if !exist {
+		return errors.New("glados: dialog step " + dialogStepKey(dialog.Name, step) + " is not registered")
+	}
+	dialog.Step = step
+	dialog.Question = question
+	dialog.Expires = time.Now().Add(s.timeout)
+	if dialog.Values == nil {
+		dialog.Values = map[string]string{}
+	}
+	if err := d.saveDialog(dialog); err != nil {
+		return err
+	}
+	return d.postDialogText(adapter, dialog, "@"+dialog.User+" "+question)
+}
+
+// EndDialog is finish dialog without waiting answer
+func (d *Dispatcher) EndDialog(dialog *Dialog) error {
+	d.dialogs.mu.Lock()
+	defer d.dialogs.mu.Unlock()
+	return d.deleteDialog(dialogKey(dialog.Adapter, dialog.Channel, dialog.ThreadID, dialog.User))
+}
+
+// saveDialog is save dialog and add it to index
+func (d *Dispatcher) saveDialog(dialog *Dialog) error {
+	d.dialogs.mu.Lock()
+	defer d.dialogs.mu.Unlock()
+	storage := d.context.Storage()
+	key := dialogKey(dialog.Adapter, dialog.Channel, dialog.ThreadID, dialog.User)
+	if err := storage.Save(dialogNamespace, key, dialog); err != nil {
+		return err
+	}
+	d.scheduleDialogExpiry()
+	keys, err := d.loadDialogKeys()
+	if err != nil {
+		return err
+	}
+	for _, k := range keys {
+		if k == key {
+			return nil
+		}
+	}
+	return storage.Save(dialogNamespace, dialogIndexKey, append(keys, key))
+}
+
+// deleteDialog is delete dialog and remove it from index. caller must hold d.dialogs.mu
+func (d *Dispatcher) deleteDialog(key string) error {
+	storage := d.context.Storage()
+	if err := storage.Delete(dialogNamespace, key); err != nil {
+		return err
+	}
+	keys, err := d.loadDialogKeys()
+	if err != nil {
+		return err
+	}
+	rest := []string{}
+	for _, k := range keys {
+		if k != key {
+			rest = append(rest, k)
+		}
+	}
+	return storage.Save(dialogNamespace, dialogIndexKey, rest)
+}
+
+func (d *Dispatcher) loadDialogKeys() ([]string, error) {
+	var keys []string
+	_, err := d.context.Storage().Load(dialogNamespace, dialogIndexKey, &keys)
+	return keys, err
+}
+
+// scheduleDialogExpiry is schedule expiry job unless it is scheduled already
+func (d *Dispatcher) scheduleDialogExpiry() {
+	d.dialogs.expiry.Do(func() {
+		if err := d.context.Scheduler().Every(dialogExpiryJob, dialogExpiryInterval, d.expireDialogs); err != nil {
+			d.context.Logger().Warnln("glados: schedule dialog expiry failed. " + err.Error())
+		}
+	})
+}
+
+// resumeDialogs is schedule expiry job if dialogs saved before restart are waiting for answer
+func (d *Dispatcher) resumeDialogs() {
+	if d.context.Storage() == nil {
+		return
+	}
+	d.dialogs.mu.Lock()
+	keys, err := d.loadDialogKeys()
+	d.dialogs.mu.Unlock()
+	if err != nil {
+		d.context.Logger().Warnln("glados: load dialogs failed. " + err.Error())
+		return
+	}
+	if len(keys) > 0 {
+		d.scheduleDialogExpiry()
+	}
+}
+
+// expireDialogs is scheduled job removing dialogs not answered in time, and telling users about timeout
+func (d *Dispatcher) expireDialogs(c Context) {
+	d.expireDialogsAt(c, time.Now())
+}
+
+func (d *Dispatcher) expireDialogsAt(c Context, now time.Time) {
+	for _, dialog := range d.takeExpiredDialogs(now) {
+		adapter := c.NamedChatAdapter(dialog.Adapter)
+		if adapter == nil {
+			c.Logger().Warnln("glados: chat adapter " + dialog.Adapter + " of dialog " + dialog.Name + " is not found")
+			continue
+		}
+		d.sayDialog(adapter, dialog, "@"+dialog.User+" timed out: "+dialog.Question)
+	}
+}
+
+// takeExpiredDialogs is load and remove dialogs expired at now
+func (d *Dispatcher) takeExpiredDialogs(now time.Time) []*Dialog {
+	d.dialogs.mu.Lock()
+	defer d.dialogs.mu.Unlock()
+	logger := d.context.Logger()
+	keys, err := d.loadDialogKeys()
+	if err != nil {
+		logger.Warnln("glados: load dialogs failed. " + err.Error())
+		return nil
+	}
+	expired := []*Dialog{}
+	for _, key := range keys {
+		dialog := &Dialog{}
+		exist, err := d.context.Storage().Load(dialogNamespace, key, dialog)
+		if err != nil {
+			logger.Warnln("glados: load dialog failed. " + err.Error())
+			continue
+		}
+		if exist && !now.After(dialog.Expires) {
+			continue
+		}
+		if err := d.deleteDialog(key); err != nil {
+			logger.Warnln("glados: delete dialog failed. " + err.Error())
+			continue
+		}
+		if exist {
+			expired = append(expired, dialog)
+		}
+	}
+	return expired
+}
+
+// answerDialog is pass event to dialog waiting for answer of event user.
+// it returns false if there is no such dialog, and event should be dispatched to handlers
+func (d *Dispatcher) answerDialog(adapter ChatAdapter, event *ChatMessageEvent) bool {
+	dialog, step, ok := d.takeDialog(event)
+	if !ok {
+		return false
+	}
+	answer := *event
+	answer.Text = strings.TrimSpace(d.mentionPattern().ReplaceAllString(event.Text, ""))
+	if dialogCancelPattern.MatchString(answer.Text) {
+		d.sayDialog(adapter, dialog, "@"+dialog.User+" canceled")
+		return true
+	}
+	if step.validate != nil {
+		if err := step.validate(answer.Text); err != nil {
+			d.sayDialog(adapter, dialog, fmt.Sprintf("@%s %s (type cancel to quit)", dialog.User, err.Error()))
+			if err := d.Ask(adapter, dialog, dialog.Step, dialog.Question); err != nil {
+				d.context.Logger().Warnln("glados: ask dialog question failed. " + err.Error())
+			}
+			return true
+		}
+	}
+	step.handle(adapter, &answer, dialog)
+	return true
+}
+
+// takeDialog is load and remove dialog waiting for answer of event user
+func (d *Dispatcher) takeDialog(event *ChatMessageEvent) (*Dialog, dialogStep, bool) {
+	if event.Command != "" {
+		return nil, dialogStep{}, false
+	}
+	d.dialogs.mu.Lock()
+	defer d.dialogs.mu.Unlock()
+	storage := d.context.Storage()
+	if storage == nil {
+		return nil, dialogStep{}, false
+	}
+	key := dialogKey(event.Adapter, event.Channel, event.ThreadID, event.User)
+	dialog := &Dialog{}
+	exist, err := storage.Load(dialogNamespace, key, dialog)
+	if err != nil {
+		d.context.Logger().Warnln("glados: load dialog failed. " + err.Error())
+		return nil, dialogStep{}, false
+	}
+	if !exist {
+		return nil, dialogStep{}, false
+	}
+	if err := d.deleteDialog(key); err != nil {
+		d.context.Logger().Warnln("glados: delete dialog failed. " + err.Error())
+	}
+	if time.Now().After(dialog.Expires) {
+		d.context.Logger().Debugln("glados: dialog " + dialog.Name + " timed out")
+		return nil, dialogStep{}, false
+	}
+	step, exist := d.dialogs.steps[dialogStepKey(dialog.Name, dialog.Step)]
+	if !exist {
+		d.context.Logger().Warnln("glados: dialog step " + dialogStepKey(dialog.Name, dialog.Step) + " is not registered")
+		return nil, dialogStep{}, false
+	}
+	return dialog, step, true
+}
+
+// mentionPattern matches bot name prefix of answer addressed to bot
+func (d *Dispatcher) mentionPattern() *regexp.Regexp {
+	return regexp.MustCompile(fmt.Sprintf(`^(?:@?(?:%s|%s)[:,]?)\s+`,
+		regexp.QuoteMeta(d.context.BotName()), regexp.QuoteMeta(d.context.BotNameAlias())))
+}
+
+func (d *Dispatcher) postDialogText(adapter ChatAdapter, dialog *Dialog, text string) error {
+	message := &ChatMessage{Text: text}
+	if dialog.ThreadID != "" {
+		_, err := adapter.PostThreadMessage(dialog.Channel, dialog.ThreadID, message)
+		return err
+	}
+	_, err := adapter.PostMessage(dialog.Channel, message)
+	return err
+}
+
+func (d *Dispatcher) sayDialog(adapter ChatAdapter, dialog *Dialog, text string) {
+	if err := d.postDialogText(adapter, dialog, text); err != nil {
+		d.context.Logger().Warnln("glados: post message failed. " + err.Error())
+	}
+}
@@ -0,0 +1,68 @@
+package glados_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/astronoka/glados"
+	"github.com/astronoka/glados/gladostest"
+)
+
+func postedText(message gladostest.PostedMessage) string {
+	if message.Message != nil {
+		return message.Message.Text
+	}
+	return message.Text
+}
+
+func TestExpireDialogs(t *testing.T) {
+	h := gladostest.New()
+	ops := h.AddChatAdapter("ops")
+	d := h.Context.Dispatcher()
+	start := func(adapter glados.ChatAdapter, message *glados.ChatMessageEvent) {
+		if err := d.StartDialog(adapter, message, "deploy", "env", "which env?"); err != nil {
+			t.Error(err)
+		}
+	}
+	d.Respond(`deploy$`, start)
+	d.DialogStep("deploy", "env", func(adapter glados.ChatAdapter, answer *glados.ChatMessageEvent, dialog *glados.Dialog) {
+		adapter.PostTextMessage(answer.Channel, "deploying "+answer.Text)
+	}, glados.WithTimeout(time.Minute))
+
+	tests := []struct {
+		name    string
+		adapter *gladostest.ChatAdapter
+		after   time.Duration
+		expired bool
+	}{
+		{"default adapter before timeout", h.ChatAdapter, 30 * time.Second, false},
+		{"default adapter after timeout", h.ChatAdapter, 2 * time.Minute, true},
+		{"named adapter after timeout", ops, 2 * time.Minute, true},
+	}
+	for _, test := range tests {
+		h.ChatAdapter.Reset()
+		ops.Reset()
+		test.adapter.Say("dev", "alice", "glados deploy")
+		if _, ok := test.adapter.WaitMessages(1, time.Second); !ok {
+			t.Errorf("%s: question is not asked", test.name)
+			continue
+		}
+		glados.ExpireDialogsAt(h.Context, time.Now().Add(test.after))
+		messages := test.adapter.Messages()
+		timedOut := len(messages) == 2 && strings.Contains(postedText(messages[1]), "@alice timed out: which env?")
+		if timedOut != test.expired {
+			t.Errorf("%s: timed out = %v, want %v. %+v", test.name, timedOut, test.expired, messages)
+		}
+
+		test.adapter.Say("dev", "alice", "production")
+		answered := false
+		if messages, ok := test.adapter.WaitMessages(len(messages)+1, 200*time.Millisecond); ok {
+			answered = strings.Contains(postedText(messages[len(messages)-1]), "deploying production")
+		}
+		if answered == test.expired {
+			t.Errorf("%s: answered = %v after expiry %v", test.name, answered, test.expired)
+		}
+		d.EndDialog(&glados.Dialog{Adapter: h.Context.ChatAdapterName(test.adapter), Channel: "dev", User: "alice"})
+	}
+}
@@ -0,0 +1,391 @@
+package glados
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Dispatcher is chat message handler registry shared by chat adapters
+type Dispatcher struct {
+	mu        sync.RWMutex
+	context   Context
+	handlers  []messageHandler
+	reactions []reactionHandler
+	actions   []actionHandler
+	slashes   []slashCommandHandler
+	dialogs   dialogs
+	incoming  []IncomingMiddleware
+	outgoing  []OutgoingMiddleware
+	roleMutex sync.Mutex
+	closed    bool
+	inflight  sync.WaitGroup
+}
+
+type messageHandler struct {
+	info   HandlerInfo
+	regexp *regexp.Regexp
+	handle ChatBotMessageHandler
+}
+
+type reactionHandler struct {
+	info   HandlerInfo
+	regexp *regexp.Regexp
+	handle ChatReactionHandler
+}
+
+// NewDispatcher is create dispatcher instance with built-in help command
+func NewDispatcher(c Context) *Dispatcher {
+	d := &Dispatcher{
+		context: c,
+	}
+	d.Respond(`(?i)help(?:\s+(.+))?$`, d.sayHelp,
+		WithHelp("help [filter]", "show commands matched filter"))
+	d.registerRoleCommands()
+	d.registerAuditCommand()
+	return d
+}
+
+// Here is register handler called for every message matched pattern
+func (d *Dispatcher) Here(pattern string, handler ChatBotMessageHandler, options ...HandlerOption) {
+	d.addHandler(HandlerInfo{Pattern: pattern}, regexp.MustCompile(pattern), handler, options)
+}
+
+// Respond is register handler called for message addressed to bot
+func (d *Dispatcher) Respond(pattern string, handler ChatBotMessageHandler, options ...HandlerOption) {
+	info := HandlerInfo{Pattern: pattern, Respond: true}
+	d.addHandler(info, regexp.MustCompile(RespondPattern(d.context, pattern)), handler, options)
+}
+
+// Command is register command handler for message addressed to bot.
+// arguments are parsed along with spec, and parse error is replied with usage
+func (d *Dispatcher) Command(spec string, handler CommandHandler, options ...HandlerOption) {
+	command := MustParseCommand(spec)
+	options = append([]HandlerOption{WithHelp(spec, "")}, options...)
+	d.Respond(command.Pattern(), func(adapter ChatAdapter, message *ChatMessageEvent) {
+		args, err := command.Parse(message.Matches[0][1])
+		if err != nil {
+			d.say(adapter, message.Channel, fmt.Sprintf("@%s %s\nusage: @%s %s",
+				message.User, err.Error(), d.context.BotName(), command.Spec))
+			return
+		}
+		handler(adapter, message, args)
+	}, options...)
+}
+
+func (d *Dispatcher) addHandler(info HandlerInfo, r *regexp.Regexp, handler ChatBotMessageHandler, options []HandlerOption) {
+	for _, option := range options {
+		option(&info)
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.handlers = append(d.handlers, messageHandler{
+		info:   info,
+		regexp: r,
+		handle: handler,
+	})
+}
+
+// Reaction is register handler called for reaction whose name matched pattern.
+// handler is called for both added and removed reaction
+func (d *Dispatcher) Reaction(pattern string, handler ChatReactionHandler, options ...HandlerOption) {
+	info := HandlerInfo{Pattern: pattern, Reaction: true}
+	for _, option := range options {
+		option(&info)
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.reactions = append(d.reactions, reactionHandler{
+		info:   info,
+		regexp: regexp.MustCompile(pattern),
+		handle: handler,
+	})
+}
+
+type actionHandler struct {
+	info   HandlerInfo
+	regexp *regexp.Regexp
+	handle ChatActionHandler
+}
+
+// Action is register handler called for button or select menu whose action id matched pattern
+func (d *Dispatcher) Action(pattern string, handler ChatActionHandler, options ...HandlerOption) {
+	info := HandlerInfo{Pattern: pattern, Action: true}
+	for _, option := range options {
+		option(&info)
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.actions = append(d.actions, actionHandler{
+		info:   info,
+		regexp: regexp.MustCompile(pattern),
+		handle: handler,
+	})
+}
+
+type slashCommandHandler struct {
+	info   HandlerInfo
+	name   string
+	handle ChatBotMessageHandler
+}
+
+// SlashCommand is register handler called for slash command such as "/deploy".
+// event Text and Matches[0][1] are arguments of the command
+func (d *Dispatcher) SlashCommand(name string, handler ChatBotMessageHandler, options ...HandlerOption) {
+	name = "/" + strings.TrimPrefix(name, "/")
+	info := HandlerInfo{Pattern: name, Slash: true, Usage: name}
+	for _, option := range options {
+		option(&info)
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.slashes = append(d.slashes, slashCommandHandler{
+		info:   info,
+		name:   name,
+		handle: handler,
+	})
+}
+
+// Handlers is return registered handler descriptions in registration order.
+// reaction, action and slash command handlers follow message handlers
+func (d *Dispatcher) Handlers() []HandlerInfo {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	infos := make([]HandlerInfo, 0, len(d.handlers)+len(d.reactions)+len(d.actions)+len(d.slashes))
+	for _, handler := range d.handlers {
+		infos = append(infos, handler.info)
+	}
+	for _, handler := range d.reactions {
+		infos = append(infos, handler.info)
+	}
+	for _, handler := range d.actions {
+		infos = append(infos, handler.info)
+	}
+	for _, handler := range d.slashes {
+		infos = append(infos, handler.info)
+	}
+	return infos
+}
+
+// Dispatch is call every handler matched event text through incoming middlewares.
+// event is passed to dialog instead if user is asked question in the channel or thread
+func (d *Dispatcher) Dispatch(adapter ChatAdapter, event *ChatMessageEvent) {
+	d.mu.RLock()
+	if d.closed {
+		d.mu.RUnlock()
+		d.context.Logger().Debugln("glados: dispatcher closed. drop message event")
+		return
+	}
+	d.inflight.Add(1)
+	defer d.inflight.Done()
+	d.mu.RUnlock()
+	if event.Adapter == "" {
+		event.Adapter = d.context.ChatAdapterName(adapter)
+	}
+	d.withIncoming(d.dispatch)(d.Adapter(adapter), event)
+}
+
+func (d *Dispatcher) dispatch(adapter ChatAdapter, event *ChatMessageEvent) {
+	if d.answerDialog(adapter, event) {
+		return
+	}
+	d.mu.RLock()
+	handlers := d.handlers
+	d.mu.RUnlock()
+	for _, handler := range handlers {
+		matches := handler.regexp.FindAllStringSubmatch(event.Text, -1)
+		if len(matches) <= 0 {
+			continue
+		}
+		if !d.authorize(adapter, handler.info, event.Channel, event.User) {
+			continue
+		}
+		if handler.info.Respond {
+			d.context.Audit().Record(AuditEntry{
+				Kind:      AuditCommand,
+				Adapter:   event.Adapter,
+				User:      event.User,
+				Channel:   event.Channel,
+				Handler:   auditHandlerName(handler.info),
+				Arguments: event.Text,
+			})
+		}
+		matched := *event
+		matched.Matches = matches
+		handler.handle(adapter, &matched)
+	}
+}
+
+// DispatchReaction is call every reaction handler matched reaction name
+func (d *Dispatcher) DispatchReaction(adapter ChatAdapter, event *ChatReactionEvent) {
+	d.mu.RLock()
+	if d.closed {
+		d.mu.RUnlock()
+		d.context.Logger().Debugln("glados: dispatcher closed. drop reaction event")
+		return
+	}
+	d.inflight.Add(1)
+	defer d.inflight.Done()
+	handlers := d.reactions
+	d.mu.RUnlock()
+	if event.Adapter == "" {
+		event.Adapter = d.context.ChatAdapterName(adapter)
+	}
+	adapter = d.Adapter(adapter)
+	for _, handler := range handlers {
+		matches := handler.regexp.FindAllStringSubmatch(event.Reaction, -1)
+		if len(matches) <= 0 {
+			continue
+		}
+		if !d.authorize(adapter, handler.info, event.Channel, event.User) {
+			continue
+		}
+		matched := *event
+		matched.Matches = matches
+		handler.handle(adapter, &matched)
+	}
+}
+
+// DispatchAction is call every action handler matched action id
+func (d *Dispatcher) DispatchAction(adapter ChatAdapter, event *ChatActionEvent) {
+	d.mu.RLock()
+	if d.closed {
+		d.mu.RUnlock()
+		d.context.Logger().Debugln("glados: dispatcher closed. drop action event")
+		return
+	}
+	d.inflight.Add(1)
+	defer d.inflight.Done()
+	handlers := d.actions
+	d.mu.RUnlock()
+	if event.Adapter == "" {
+		event.Adapter = d.context.ChatAdapterName(adapter)
+	}
+	adapter = d.Adapter(adapter)
+	for _, handler := range handlers {
+		matches := handler.regexp.FindAllStringSubmatch(event.ActionID, -1)
+		if len(matches) <= 0 {
+			continue
+		}
+		if !d.authorize(adapter, handler.info, event.Channel, event.User) {
+			continue
+		}
+		d.context.Audit().Record(AuditEntry{
+			Kind:      AuditCommand,
+			Adapter:   event.Adapter,
+			User:      event.User,
+			Channel:   event.Channel,
+			Handler:   "action " + event.ActionID,
+			Arguments: event.Value,
+		})
+		matched := *event
+		matched.Matches = matches
+		handler.handle(adapter, &matched)
+	}
+}
+
+// DispatchSlashCommand is call first handler registered for event command through incoming middlewares.
+// it returns false if no handler is registered or dispatcher is closed
+func (d *Dispatcher) DispatchSlashCommand(adapter ChatAdapter, event *ChatMessageEvent) bool {
+	d.mu.RLock()
+	if d.closed {
+		d.mu.RUnlock()
+		d.context.Logger().Debugln("glados: dispatcher closed. drop slash command")
+		return false
+	}
+	d.inflight.Add(1)
+	defer d.inflight.Done()
+	handlers := d.slashes
+	d.mu.RUnlock()
+	if event.Adapter == "" {
+		event.Adapter = d.context.ChatAdapterName(adapter)
+	}
+	name := "/" + strings.TrimPrefix(event.Command, "/")
+	for _, handler := range handlers {
+		if handler.name != name {
+			continue
+		}
+		matched := *event
+		matched.Command = name
+		matched.Matches = [][]string{{strings.TrimSpace(name + " " + event.Text), event.Text}}
+		info, handle := handler.info, handler.handle
+		d.withIncoming(func(adapter ChatAdapter, event *ChatMessageEvent) {
+			if !d.authorize(adapter, info, event.Channel, event.User) {
+				return
+			}
+			d.context.Audit().Record(AuditEntry{
+				Kind:      AuditCommand,
+				Adapter:   event.Adapter,
+				User:      event.User,
+				Channel:   event.Channel,
+				Handler:   event.Command,
+				Arguments: event.Text,
+			})
+			handle(adapter, event)
+		})(d.Adapter(adapter), &matched)
+		return true
+	}
+	return false
+}
+
+// Shutdown is stop dispatching new event and wait for in-flight handlers
+func (d *Dispatcher) Shutdown(ctx context.Context) error {
+	d.mu.Lock()
+	d.closed = true
+	d.mu.Unlock()
+	drained := make(chan struct{})
+	go func() {
+		d.inflight.Wait()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// RespondPattern is build regexp pattern for message addressed to bot
+func RespondPattern(c Context, pattern string) string {
+	return fmt.Sprintf(`^(?:@?(?:%s|%s)[:,]?)\s+(?:%s)`,
+		c.BotName(), c.BotNameAlias(), pattern)
+}
+
+func (d *Dispatcher) sayHelp(adapter ChatAdapter, event *ChatMessageEvent) {
+	filter := strings.ToLower(strings.TrimSpace(event.Matches[0][1]))
+	var lines []string
+	for _, info := range d.Handlers() {
+		if info.Usage == "" {
+			continue
+		}
+		usage := info.Usage
+		if info.Respond {
+			usage = "@" + d.context.BotName() + " " + usage
+		}
+		line := usage
+		if info.Description != "" {
+			line += " - " + info.Description
+		}
+		if len(info.Roles) > 0 {
+			line += " (" + strings.Join(info.Roles, " or ") + ")"
+		}
+		if filter != "" && !strings.Contains(strings.ToLower(line), filter) {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) <= 0 {
+		d.say(adapter, event.Channel, "no command matched "+filter)
+		return
+	}
+	d.say(adapter, event.Channel, strings.Join(lines, "\n"))
+}
+
+func (d *Dispatcher) say(adapter ChatAdapter, channel, text string) {
+	if _, err := adapter.PostTextMessage(channel, text); err != nil {
+		d.context.Logger().Warnln("glados: post message failed. " + err.Error())
+	}
+}
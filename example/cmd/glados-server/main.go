@@ -1,8 +1,16 @@
 package main
 
 import (
+	"context"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
 	"github.com/Sirupsen/logrus"
 	"github.com/astronoka/glados"
+	"github.com/astronoka/glados/chatadapter/shellbind"
 	"github.com/astronoka/glados/chatadapter/slackbind"
 	"github.com/astronoka/glados/program/githubnotifier"
 	"github.com/astronoka/glados/router/ginbind"
@@ -24,11 +32,57 @@ func main() {
 	context.SetLogger(logger)
 	context.SetStorage(memory.NewStorage(context))
 	context.SetRouter(ginbind.NewRouter(context))
-	context.SetChatAdapter(slackbind.NewChatAdapter(context))
+	// comma separated adapters are registered by their names. the first is default.
+	// name=kind entry names adapter, and slack adapter of the entry reads GLADOS_SLACK_<NAME>_* env
+	unscopedSlack := false
+	for _, entry := range strings.Split(context.Env("GLADOS_CHAT_ADAPTER", "slack"), ",") {
+		name, kind, scope := parseChatAdapterEntry(entry)
+		if (kind == "slack" || kind == "slack-events") && scope == "" {
+			if unscopedSlack {
+				logger.Fatalln("Glados: only one slack adapter can be unnamed. name others as name=" + kind)
+			}
+			unscopedSlack = true
+		}
+		switch kind {
+		case "shell":
+			context.AddChatAdapter(name, shellbind.NewChatAdapter(context))
+		case "slack-events":
+			context.AddChatAdapter(name, slackbind.NewNamedEventsAPIChatAdapter(context, scope))
+		case "slack":
+			context.AddChatAdapter(name, slackbind.NewNamedChatAdapter(context, scope))
+		default:
+			logger.Fatalln("Glados: unknown chat adapter " + kind + " (slack, slack-events or shell)")
+		}
+	}
 
 	glados := glados.New(context)
 	glados.Install(githubnotifier.NewProgram(map[string]string{
 		"githubUserName": "slackUserName",
 	}))
+	go shutdownOnSignal(glados, logger)
 	glados.Boot()
 }
+
+// parseChatAdapterEntry is return adapter name, kind and env scope of GLADOS_CHAT_ADAPTER entry.
+// kind is name of adapter without name= prefix, and such adapter has no scope
+func parseChatAdapterEntry(entry string) (string, string, string) {
+	entry = strings.TrimSpace(entry)
+	i := strings.Index(entry, "=")
+	if i < 0 {
+		return entry, entry, ""
+	}
+	name := strings.TrimSpace(entry[:i])
+	return name, strings.TrimSpace(entry[i+1:]), name
+}
+
+func shutdownOnSignal(g *glados.Glados, logger glados.Logger) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	sig := <-signals
+	logger.Infoln("Glados: received " + sig.String())
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := g.Shutdown(ctx); err != nil {
+		logger.Errorln(err.Error())
+	}
+}
@@ -0,0 +1,19 @@
+package glados
+
+import "time"
+
+// ExpireDialogsAt is run dialog expiry job as if it is now, for external tests
+func ExpireDialogsAt(c Context, now time.Time) {
+	c.Dispatcher().expireDialogsAt(c, now)
+}
+
+// DialogExpiryJob is name of dialog expiry job, for external tests
+const DialogExpiryJob = dialogExpiryJob
+
+// Scheduled is return true if job of name is registered to scheduler, for external tests
+func Scheduled(s *Scheduler, name string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, exist := s.jobs[name]
+	return exist
+}
@@ -1,27 +1,122 @@
 package glados
 
-// New is create glados instance
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+)
+
+// New is create glados instance. audit endpoint is mounted on context router if GLADOS_AUDIT_ADMIN_TOKEN is set
 func New(c Context) *Glados {
+	mountAudit(c)
 	return &Glados{
-		context: c,
+		context:  c,
+		shutdown: make(chan struct{}),
+		done:     make(chan struct{}),
 	}
 }
 
 // Glados is Github Lifeform and Disk Operating System
 type Glados struct {
-	context Context
+	context      Context
+	mu           sync.Mutex
+	programs     []Program
+	shutdownOnce sync.Once
+	shutdown     chan struct{}
+	done         chan struct{}
 }
 
-// Boot is boot up glados instance
-func (g Glados) Boot() {
+// Boot is boot up glados instance. it blocks until Shutdown is completed
+func (g *Glados) Boot() {
 	logger := g.context.Logger()
 	logger.Infoln("Glados: Boot..")
-	defer logger.Infoln("Glados: Shutdown..")
+	for _, p := range g.installedPrograms() {
+		starter, ok := p.(Starter)
+		if !ok {
+			continue
+		}
+		if err := starter.Start(g.context); err != nil {
+			logger.Fatalln("Glados: start program failed. " + err.Error())
+		}
+	}
+	g.context.Dispatcher().resumeDialogs()
+	g.context.Scheduler().Start()
 	port := g.context.ListenPort()
 	g.context.Router().RunWithPort(port)
+	select {
+	case <-g.shutdown:
+		<-g.done
+	default:
+		logger.Errorln("Glados: router stopped without shutdown")
+	}
+}
+
+// Shutdown is stop router, drain chat handlers and scheduled jobs, stop programs and close chat adapters and storage
+func (g *Glados) Shutdown(ctx context.Context) error {
+	started := false
+	g.shutdownOnce.Do(func() {
+		close(g.shutdown)
+		started = true
+	})
+	if !started {
+		return errors.New("glados: already shutdown")
+	}
+	defer close(g.done)
+
+	logger := g.context.Logger()
+	logger.Infoln("Glados: Shutdown..")
+	var messages []string
+	if err := g.context.Router().Shutdown(ctx); err != nil {
+		messages = append(messages, "router: "+err.Error())
+	}
+	if err := g.context.Dispatcher().Shutdown(ctx); err != nil {
+		messages = append(messages, "dispatcher: "+err.Error())
+	}
+	if err := g.context.Scheduler().Stop(ctx); err != nil {
+		messages = append(messages, "scheduler: "+err.Error())
+	}
+	programs := g.installedPrograms()
+	for i := len(programs) - 1; i >= 0; i-- {
+		stopper, ok := programs[i].(Stopper)
+		if !ok {
+			continue
+		}
+		if err := stopper.Stop(ctx); err != nil {
+			messages = append(messages, "program: "+err.Error())
+		}
+	}
+	for _, name := range g.context.ChatAdapterNames() {
+		if closer, ok := g.context.NamedChatAdapter(name).(io.Closer); ok {
+			if err := closer.Close(); err != nil {
+				messages = append(messages, "chatadapter "+name+": "+err.Error())
+			}
+		}
+	}
+	if closer, ok := g.context.Storage().(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			messages = append(messages, "storage: "+err.Error())
+		}
+	}
+	if len(messages) > 0 {
+		return errors.New("glados: shutdown failed. " + strings.Join(messages, ", "))
+	}
+	return nil
 }
 
 // Install is register glados logic
 func (g *Glados) Install(p Program) {
 	p.Initialize(g.context)
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.programs = append(g.programs, p)
+}
+
+func (g *Glados) installedPrograms() []Program {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	programs := make([]Program, len(g.programs))
+	copy(programs, g.programs)
+	return programs
 }
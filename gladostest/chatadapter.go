@@ -0,0 +1,328 @@
+package gladostest
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/astronoka/glados"
+)
+
+// PostedMessage is message posted through fake chat adapter
+type PostedMessage struct {
+	MessageID string
+	Updated   bool
+	Deleted   bool
+	Channel   string
+	ThreadID  string
+	User      string
+	Ephemeral bool
+	Direct    bool
+	Text      string
+	Message   *glados.ChatMessage
+}
+
+// AddedReaction is reaction added or removed through fake chat adapter
+type AddedReaction struct {
+	Channel   string
+	MessageID string
+	Name      string
+	Removed   bool
+}
+
+// ChatAdapter is fake chat adapter recording posted messages
+type ChatAdapter struct {
+	mu        sync.Mutex
+	context   glados.Context
+	messages  []PostedMessage
+	reactions []AddedReaction
+	lastID    int
+	err       error
+}
+
+// NewChatAdapter is create fake chat adapter instance
+func NewChatAdapter(c glados.Context) *ChatAdapter {
+	return &ChatAdapter{
+		context: c,
+	}
+}
+
+// PostTextMessage is record text message
+func (a *ChatAdapter) PostTextMessage(channel, text string) (glados.ChatMessageRef, error) {
+	if err := a.failure(); err != nil {
+		return glados.ChatMessageRef{}, err
+	}
+	return a.record(PostedMessage{
+		Channel: channel,
+		Text:    text,
+	}), nil
+}
+
+// PostMessage is record message
+func (a *ChatAdapter) PostMessage(channel string, message *glados.ChatMessage) (glados.ChatMessageRef, error) {
+	if err := a.failure(); err != nil {
+		return glados.ChatMessageRef{}, err
+	}
+	return a.record(PostedMessage{
+		Channel: channel,
+		Message: message,
+	}), nil
+}
+
+// PostThreadMessage is record thread message
+func (a *ChatAdapter) PostThreadMessage(channel, threadID string, message *glados.ChatMessage) (glados.ChatMessageRef, error) {
+	if err := a.failure(); err != nil {
+		return glados.ChatMessageRef{}, err
+	}
+	return a.record(PostedMessage{
+		Channel:  channel,
+		ThreadID: threadID,
+		Message:  message,
+	}), nil
+}
+
+// PostEphemeralMessage is record ephemeral message
+func (a *ChatAdapter) PostEphemeralMessage(channel, user string, message *glados.ChatMessage) error {
+	if err := a.failure(); err != nil {
+		return err
+	}
+	a.record(PostedMessage{
+		Channel:   channel,
+		User:      user,
+		Ephemeral: true,
+		Message:   message,
+	})
+	return nil
+}
+
+// PostDirectMessage is record direct message
+func (a *ChatAdapter) PostDirectMessage(user string, message *glados.ChatMessage) (glados.ChatMessageRef, error) {
+	if err := a.failure(); err != nil {
+		return glados.ChatMessageRef{}, err
+	}
+	return a.record(PostedMessage{
+		User:    user,
+		Direct:  true,
+		Message: message,
+	}), nil
+}
+
+// UpdateMessage is record updated message
+func (a *ChatAdapter) UpdateMessage(ref glados.ChatMessageRef, message *glados.ChatMessage) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.err != nil {
+		return a.err
+	}
+	a.messages = append(a.messages, PostedMessage{
+		MessageID: ref.ID,
+		Updated:   true,
+		Channel:   ref.Channel,
+		Message:   message,
+	})
+	return nil
+}
+
+// DeleteMessage is record deleted message
+func (a *ChatAdapter) DeleteMessage(ref glados.ChatMessageRef) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.err != nil {
+		return a.err
+	}
+	a.messages = append(a.messages, PostedMessage{
+		MessageID: ref.ID,
+		Deleted:   true,
+		Channel:   ref.Channel,
+	})
+	return nil
+}
+
+// AddReaction is record added reaction
+func (a *ChatAdapter) AddReaction(ref glados.ChatMessageRef, name string) error {
+	return a.recordReaction(ref, name, false)
+}
+
+// RemoveReaction is record removed reaction
+func (a *ChatAdapter) RemoveReaction(ref glados.ChatMessageRef, name string) error {
+	return a.recordReaction(ref, name, true)
+}
+
+func (a *ChatAdapter) recordReaction(ref glados.ChatMessageRef, name string, removed bool) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.err != nil {
+		return a.err
+	}
+	a.reactions = append(a.reactions, AddedReaction{
+		Channel:   ref.Channel,
+		MessageID: ref.ID,
+		Name:      strings.Trim(name, ":"),
+		Removed:   removed,
+	})
+	return nil
+}
+
+// Reactions is return added and removed reactions in called order
+func (a *ChatAdapter) Reactions() []AddedReaction {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	reactions := make([]AddedReaction, len(a.reactions))
+	copy(reactions, a.reactions)
+	return reactions
+}
+
+// Fail is make posts, updates, deletions and reactions return err until Fail(nil) is called
+func (a *ChatAdapter) Fail(err error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.err = err
+}
+
+func (a *ChatAdapter) failure() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.err
+}
+
+// Here is register handler to context dispatcher
+func (a *ChatAdapter) Here(pattern string, handler glados.ChatBotMessageHandler, options ...glados.HandlerOption) {
+	a.context.Dispatcher().Here(pattern, handler, options...)
+}
+
+// Respond is register handler to context dispatcher
+func (a *ChatAdapter) Respond(pattern string, handler glados.ChatBotMessageHandler, options ...glados.HandlerOption) {
+	a.context.Dispatcher().Respond(pattern, handler, options...)
+}
+
+// Reaction is register reaction handler to context dispatcher
+func (a *ChatAdapter) Reaction(pattern string, handler glados.ChatReactionHandler, options ...glados.HandlerOption) {
+	a.context.Dispatcher().Reaction(pattern, handler, options...)
+}
+
+// Action is register action handler to context dispatcher
+func (a *ChatAdapter) Action(pattern string, handler glados.ChatActionHandler, options ...glados.HandlerOption) {
+	a.context.Dispatcher().Action(pattern, handler, options...)
+}
+
+// SlashCommand is register slash command handler to context dispatcher
+func (a *ChatAdapter) SlashCommand(name string, handler glados.ChatBotMessageHandler, options ...glados.HandlerOption) {
+	a.context.Dispatcher().SlashCommand(name, handler, options...)
+}
+
+// Inject is dispatch event as if it came from chat system
+func (a *ChatAdapter) Inject(event *glados.ChatMessageEvent) {
+	a.context.Dispatcher().Dispatch(a, event)
+}
+
+// Say is dispatch text message from user in channel
+func (a *ChatAdapter) Say(channel, user, text string) {
+	a.Inject(&glados.ChatMessageEvent{
+		Channel:   channel,
+		User:      user,
+		Text:      text,
+		MessageID: a.nextID(),
+	})
+}
+
+// React is dispatch reaction added by user to message
+func (a *ChatAdapter) React(channel, user, messageID, name string) {
+	a.context.Dispatcher().DispatchReaction(a, &glados.ChatReactionEvent{
+		Channel:   channel,
+		User:      user,
+		Reaction:  strings.Trim(name, ":"),
+		MessageID: messageID,
+	})
+}
+
+// Unreact is dispatch reaction removed by user from message
+func (a *ChatAdapter) Unreact(channel, user, messageID, name string) {
+	a.context.Dispatcher().DispatchReaction(a, &glados.ChatReactionEvent{
+		Channel:   channel,
+		User:      user,
+		Reaction:  strings.Trim(name, ":"),
+		MessageID: messageID,
+		Removed:   true,
+	})
+}
+
+// Slash is dispatch slash command run by user in channel, and return false if command is unknown
+func (a *ChatAdapter) Slash(channel, user, command, text string) bool {
+	return a.context.Dispatcher().DispatchSlashCommand(a, &glados.ChatMessageEvent{
+		Channel: channel,
+		User:    user,
+		Text:    text,
+		Command: command,
+	})
+}
+
+// Click is dispatch button clicked or menu option selected by user on message
+func (a *ChatAdapter) Click(channel, user, messageID, actionID, value string) {
+	a.context.Dispatcher().DispatchAction(a, &glados.ChatActionEvent{
+		Channel:   channel,
+		User:      user,
+		ActionID:  actionID,
+		Value:     value,
+		MessageID: messageID,
+	})
+}
+
+// Messages is return posted messages in posted order
+func (a *ChatAdapter) Messages() []PostedMessage {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	messages := make([]PostedMessage, len(a.messages))
+	copy(messages, a.messages)
+	return messages
+}
+
+// WaitMessages is wait until n messages are posted, and return posted messages
+func (a *ChatAdapter) WaitMessages(n int, timeout time.Duration) ([]PostedMessage, bool) {
+	deadline := time.Now().Add(timeout)
+	for {
+		messages := a.Messages()
+		if len(messages) >= n {
+			return messages, true
+		}
+		if time.Now().After(deadline) {
+			return messages, false
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// Reset is forget posted messages and reactions
+func (a *ChatAdapter) Reset() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.messages = nil
+	a.reactions = nil
+}
+
+// Message is return last posted, updated or deleted message of id
+func (a *ChatAdapter) Message(id string) (PostedMessage, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for i := len(a.messages) - 1; i >= 0; i-- {
+		if a.messages[i].MessageID == id {
+			return a.messages[i], true
+		}
+	}
+	return PostedMessage{}, false
+}
+
+func (a *ChatAdapter) nextID() string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.lastID++
+	return strconv.Itoa(a.lastID)
+}
+
+func (a *ChatAdapter) record(m PostedMessage) glados.ChatMessageRef {
+	m.MessageID = a.nextID()
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.messages = append(a.messages, m)
+	return glados.ChatMessageRef{Channel: m.Channel, ID: m.MessageID}
+}
@@ -0,0 +1,88 @@
+// Package gladostest provides fake glados components to test programs in process.
+package gladostest
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/hex"
+	"net/http"
+	"time"
+
+	"github.com/astronoka/glados"
+	"github.com/astronoka/glados/storage/memory"
+)
+
+// Harness is glados context wired with fake components
+type Harness struct {
+	Glados      *glados.Glados
+	Context     glados.Context
+	Logger      *Logger
+	Router      *Router
+	ChatAdapter *ChatAdapter
+}
+
+// New is create harness with fake chat adapter, fake router, memory storage and recording logger
+func New() *Harness {
+	c := glados.NewContext("GLaDOS", "glados", "0")
+	h := &Harness{
+		Context:     c,
+		Logger:      NewLogger(),
+		Router:      NewRouter(),
+		ChatAdapter: NewChatAdapter(c),
+	}
+	c.SetLogger(h.Logger)
+	c.SetStorage(memory.NewStorage(c))
+	c.SetRouter(h.Router)
+	c.SetChatAdapter(h.ChatAdapter)
+	h.Glados = glados.New(c)
+	return h
+}
+
+// NewContext is create ready-made context with fake components
+func NewContext() glados.Context {
+	return New().Context
+}
+
+// AddChatAdapter is create fake chat adapter registered to harness context by name
+func (h *Harness) AddChatAdapter(name string) *ChatAdapter {
+	adapter := NewChatAdapter(h.Context)
+	h.Context.AddChatAdapter(name, adapter)
+	return adapter
+}
+
+// Install is initialize program with harness context
+func (h *Harness) Install(p glados.Program) {
+	h.Glados.Install(p)
+}
+
+// Boot is boot glados in background so that program starters and scheduler run
+func (h *Harness) Boot() {
+	go h.Glados.Boot()
+}
+
+// Shutdown is shutdown glados booted by Boot
+func (h *Harness) Shutdown() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	return h.Glados.Shutdown(ctx)
+}
+
+// NewGitHubWebhookRequest is create webhook request signed same as github, with new delivery id
+func NewGitHubWebhookRequest(path, eventType, secret string, payload []byte) *http.Request {
+	req, err := http.NewRequest(http.MethodPost, path, bytes.NewReader(payload))
+	if err != nil {
+		panic("gladostest: " + err.Error())
+	}
+	mac := hmac.New(sha1.New, []byte(secret))
+	mac.Write(payload)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-GitHub-Event", eventType)
+	req.Header.Set("X-Hub-Signature", "sha1="+hex.EncodeToString(mac.Sum(nil)))
+	id := make([]byte, 16)
+	rand.Read(id)
+	req.Header.Set("X-GitHub-Delivery", hex.EncodeToString(id))
+	return req
+}
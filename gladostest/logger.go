@@ -0,0 +1,87 @@
+package gladostest
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Logger is logger recording every line
+type Logger struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+// NewLogger is create recording logger instance
+func NewLogger() *Logger {
+	return &Logger{}
+}
+
+// Lines is return recorded lines formatted as "LEVEL message"
+func (l *Logger) Lines() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	lines := make([]string, len(l.lines))
+	copy(lines, l.lines)
+	return lines
+}
+
+func (l *Logger) record(level, message string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.lines = append(l.lines, level+" "+strings.TrimRight(message, "\n"))
+}
+
+// Debugf is record debug log
+func (l *Logger) Debugf(format string, args ...interface{}) {
+	l.record("DEBUG", fmt.Sprintf(format, args...))
+}
+
+// Debugln is record debug log
+func (l *Logger) Debugln(args ...interface{}) {
+	l.record("DEBUG", fmt.Sprintln(args...))
+}
+
+// Infof is record info log
+func (l *Logger) Infof(format string, args ...interface{}) {
+	l.record("INFO", fmt.Sprintf(format, args...))
+}
+
+// Infoln is record info log
+func (l *Logger) Infoln(args ...interface{}) {
+	l.record("INFO", fmt.Sprintln(args...))
+}
+
+// Warnf is record warn log
+func (l *Logger) Warnf(format string, args ...interface{}) {
+	l.record("WARN", fmt.Sprintf(format, args...))
+}
+
+// Warnln is record warn log
+func (l *Logger) Warnln(args ...interface{}) {
+	l.record("WARN", fmt.Sprintln(args...))
+}
+
+// Errorf is record error log
+func (l *Logger) Errorf(format string, args ...interface{}) {
+	l.record("ERROR", fmt.Sprintf(format, args...))
+}
+
+// Errorln is record error log
+func (l *Logger) Errorln(args ...interface{}) {
+	l.record("ERROR", fmt.Sprintln(args...))
+}
+
+// Fatalf is record fatal log and panic
+func (l *Logger) Fatalf(format string, args ...interface{}) {
+	message := fmt.Sprintf(format, args...)
+	l.record("FATAL", message)
+	panic(message)
+}
+
+// Fatalln is record fatal log and panic
+func (l *Logger) Fatalln(args ...interface{}) {
+	message := fmt.Sprintln(args...)
+	l.record("FATAL", message)
+	panic(message)
+}
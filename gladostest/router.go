@@ -0,0 +1,138 @@
+package gladostest
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+
+	"github.com/astronoka/glados"
+)
+
+// Router is fake router dispatching http requests to registered handlers
+type Router struct {
+	mu       sync.RWMutex
+	routes   []route
+	shutdown chan struct{}
+	once     sync.Once
+}
+
+type route struct {
+	method   string
+	segments []string
+	handler  glados.RequestHandler
+}
+
+// NewRouter is create fake router instance
+func NewRouter() *Router {
+	return &Router{
+		shutdown: make(chan struct{}),
+	}
+}
+
+// GET is register handler for GET request
+func (r *Router) GET(path string, handler glados.RequestHandler) {
+	r.addRoute(http.MethodGet, path, handler)
+}
+
+// POST is register handler for POST request
+func (r *Router) POST(path string, handler glados.RequestHandler) {
+	r.addRoute(http.MethodPost, path, handler)
+}
+
+// RunWithPort is block until Shutdown. use ServeHTTP or Do to send request
+func (r *Router) RunWithPort(port string) {
+	<-r.shutdown
+}
+
+// Shutdown is release RunWithPort
+func (r *Router) Shutdown(ctx context.Context) error {
+	r.once.Do(func() {
+		close(r.shutdown)
+	})
+	return nil
+}
+
+func (r *Router) addRoute(method, path string, handler glados.RequestHandler) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.routes = append(r.routes, route{
+		method:   method,
+		segments: splitPath(path),
+		handler:  handler,
+	})
+}
+
+// ServeHTTP is dispatch request to registered handler
+func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mu.RLock()
+	routes := r.routes
+	r.mu.RUnlock()
+	segments := splitPath(req.URL.Path)
+	for _, route := range routes {
+		if route.method != req.Method {
+			continue
+		}
+		params, ok := matchSegments(route.segments, segments)
+		if !ok {
+			continue
+		}
+		route.handler(&requestContext{
+			params:  params,
+			request: req,
+			writer:  w,
+		})
+		return
+	}
+	http.NotFound(w, req)
+}
+
+// Do is dispatch request and return recorded response
+func (r *Router) Do(req *http.Request) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	r.ServeHTTP(recorder, req)
+	return recorder
+}
+
+func splitPath(path string) []string {
+	return strings.Split(strings.Trim(path, "/"), "/")
+}
+
+func matchSegments(pattern, segments []string) (map[string]string, bool) {
+	if len(pattern) != len(segments) {
+		return nil, false
+	}
+	params := map[string]string{}
+	for i, p := range pattern {
+		if strings.HasPrefix(p, ":") {
+			params[p[1:]] = segments[i]
+			continue
+		}
+		if p != segments[i] {
+			return nil, false
+		}
+	}
+	return params, true
+}
+
+type requestContext struct {
+	params  map[string]string
+	request *http.Request
+	writer  http.ResponseWriter
+}
+
+func (rc *requestContext) Param(key string) string {
+	return rc.params[key]
+}
+
+func (rc *requestContext) Request() *http.Request {
+	return rc.request
+}
+
+func (rc *requestContext) JSON(code int, obj interface{}) {
+	rc.writer.Header().Set("Content-Type", "application/json; charset=utf-8")
+	rc.writer.WriteHeader(code)
+	json.NewEncoder(rc.writer).Encode(obj)
+}
@@ -0,0 +1,235 @@
+package glados
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+)
+
+// MessageField is titled value shown in table of message.
+// short fields may be laid out side by side
+type MessageField struct {
+	Title string
+	Value string
+	Short bool
+}
+
+// MessageSection is block of message following main text
+type MessageSection struct {
+	Title    string
+	Text     string
+	Fields   []MessageField
+	ImageURL string
+}
+
+// action types
+const (
+	ActionButton = "button"
+	ActionSelect = "select"
+)
+
+// action styles
+const (
+	ActionStylePrimary = "primary"
+	ActionStyleDanger  = "danger"
+)
+
+// MessageAction is button or select menu attached to message.
+// ActionID is matched against pattern of handler registered by Action
+type MessageAction struct {
+	Type     string
+	ActionID string
+	Text     string
+	Value    string
+	Style    string
+	Options  []ActionOption
+}
+
+// ActionOption is option of select menu
+type ActionOption struct {
+	Text  string
+	Value string
+}
+
+// markupLinkPattern matches slack style link such as <url|text> or <url>
+var markupLinkPattern = regexp.MustCompile(`<((?:https?|mailto):[^|>]+)(?:\|([^>]+))?>`)
+
+// timestampLayout is layout of message timestamp degraded to text
+const timestampLayout = "2006-01-02 15:04 MST"
+
+// Markdown is degrade message to markdown for adapters without rich message support
+func (m *ChatMessage) Markdown() string {
+	lines := []string{}
+	if m.Author.Name != "" {
+		author := "**" + markdownLink(m.Author.Link, m.Author.Name) + "**"
+		if m.Author.Subname != "" {
+			author += " " + m.Author.Subname
+		}
+		lines = append(lines, author)
+	}
+	if m.Title != "" {
+		lines = append(lines, "### "+markdownLink(m.TitleLinkURL, m.Title))
+	}
+	lines = appendText(lines, markdownText(m.Text))
+	lines = appendMarkdownFields(lines, m.Fields)
+	if m.ImageURL != "" {
+		lines = append(lines, "![]("+m.ImageURL+")")
+	}
+	for _, section := range m.Sections {
+		lines = append(lines, "---")
+		if section.Title != "" {
+			lines = append(lines, "#### "+section.Title)
+		}
+		lines = appendText(lines, markdownText(section.Text))
+		lines = appendMarkdownFields(lines, section.Fields)
+		if section.ImageURL != "" {
+			lines = append(lines, "![]("+section.ImageURL+")")
+		}
+	}
+	if len(m.Actions) > 0 {
+		lines = append(lines, actionsText(m.Actions))
+	}
+	for _, context := range m.Context {
+		lines = append(lines, "_"+markdownText(context)+"_")
+	}
+	if footer := m.footer(); footer != "" {
+		lines = append(lines, "_"+markdownText(footer)+"_")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// PlainText is degrade message to plain text for adapters without any markup
+func (m *ChatMessage) PlainText() string {
+	lines := []string{}
+	if m.Author.Name != "" {
+		author := m.Author.Name
+		if m.Author.Subname != "" {
+			author += " (" + m.Author.Subname + ")"
+		}
+		lines = append(lines, author)
+	}
+	if m.Title != "" {
+		title := m.Title
+		if m.TitleLinkURL != "" {
+			title += " <" + m.TitleLinkURL + ">"
+		}
+		lines = append(lines, title)
+	}
+	lines = appendText(lines, plainText(m.Text))
+	lines = appendPlainFields(lines, m.Fields)
+	if m.ImageURL != "" {
+		lines = append(lines, "<"+m.ImageURL+">")
+	}
+	for _, section := range m.Sections {
+		lines = append(lines, "")
+		if section.Title != "" {
+			lines = append(lines, section.Title)
+		}
+		lines = appendText(lines, plainText(section.Text))
+		lines = appendPlainFields(lines, section.Fields)
+		if section.ImageURL != "" {
+			lines = append(lines, "<"+section.ImageURL+">")
+		}
+	}
+	if len(m.Actions) > 0 {
+		lines = append(lines, actionsText(m.Actions))
+	}
+	for _, context := range m.Context {
+		lines = append(lines, plainText(context))
+	}
+	if footer := m.footer(); footer != "" {
+		lines = append(lines, plainText(footer))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// footer is return footer joined with formatted timestamp
+func (m *ChatMessage) footer() string {
+	if m.Timestamp.IsZero() {
+		return m.Footer
+	}
+	if m.Footer == "" {
+		return m.Timestamp.Format(timestampLayout)
+	}
+	return m.Footer + " | " + m.Timestamp.Format(timestampLayout)
+}
+
+// actionsText is degrade actions to text showing action ids and values to choose
+func actionsText(actions []MessageAction) string {
+	texts := []string{}
+	for _, action := range actions {
+		if action.Type != ActionSelect {
+			texts = append(texts, "["+action.Text+": "+strings.TrimSpace(action.ActionID+" "+action.Value)+"]")
+			continue
+		}
+		values := []string{}
+		for _, option := range action.Options {
+			values = append(values, option.Value)
+		}
+		texts = append(texts, "["+action.Text+": "+action.ActionID+" "+strings.Join(values, "|")+"]")
+	}
+	return strings.Join(texts, " ")
+}
+
+func appendText(lines []string, text string) []string {
+	if text == "" {
+		return lines
+	}
+	return append(lines, strings.Split(text, "\n")...)
+}
+
+func appendMarkdownFields(lines []string, fields []MessageField) []string {
+	for _, field := range fields {
+		lines = append(lines, "**"+field.Title+"**: "+markdownText(field.Value))
+	}
+	return lines
+}
+
+func appendPlainFields(lines []string, fields []MessageField) []string {
+	for _, field := range fields {
+		lines = append(lines, field.Title+": "+plainText(field.Value))
+	}
+	return lines
+}
+
+func markdownLink(url, text string) string {
+	if url == "" {
+		return text
+	}
+	return "[" + text + "](" + url + ")"
+}
+
+func markdownText(text string) string {
+	return replaceMarkupLinks(text, func(url, text string) string {
+		if text == url {
+			return url
+		}
+		return markdownLink(url, text)
+	})
+}
+
+func plainText(text string) string {
+	return replaceMarkupLinks(text, func(url, text string) string {
+		if text == url {
+			return url
+		}
+		return text + " (" + url + ")"
+	})
+}
+
+func replaceMarkupLinks(text string, replace func(url, text string) string) string {
+	var b bytes.Buffer
+	last := 0
+	for _, m := range markupLinkPattern.FindAllStringSubmatchIndex(text, -1) {
+		b.WriteString(text[last:m[0]])
+		url := text[m[2]:m[3]]
+		linkText := url
+		if m[4] >= 0 {
+			linkText = text[m[4]:m[5]]
+		}
+		b.WriteString(replace(url, linkText))
+		last = m[1]
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
@@ -0,0 +1,140 @@
+package glados
+
+import "io"
+
+// IncomingMiddleware is wrap handling of message event from chat system.
+// middleware may stop handling by not calling next, and attach values to event by Set
+type IncomingMiddleware func(next ChatBotMessageHandler) ChatBotMessageHandler
+
+// OutgoingMiddleware is wrap posting message to chat system.
+// middleware may rewrite post, or stop posting by not calling next
+type OutgoingMiddleware func(next PostFunc) PostFunc
+
+// PostFunc is function posting message through chat adapter
+type PostFunc func(post *OutgoingPost) (ChatMessageRef, error)
+
+// kinds of outgoing post
+const (
+	PostKindMessage   = "message"
+	PostKindThread    = "thread"
+	PostKindEphemeral = "ephemeral"
+	PostKindDirect    = "direct"
+	PostKindUpdate    = "update"
+)
+
+// OutgoingPost is message being posted. Channel, ThreadID, User and Ref are set by Kind
+type OutgoingPost struct {
+	Kind     string
+	Channel  string
+	ThreadID string
+	User     string
+	Ref      ChatMessageRef
+	Message  *ChatMessage
+}
+
+// UseIncoming is add middlewares around dispatching message events and slash commands.
+// middleware added first runs first
+func (d *Dispatcher) UseIncoming(middlewares ...IncomingMiddleware) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.incoming = append(d.incoming, middlewares...)
+}
+
+// UseOutgoing is add middlewares around posting messages by adapters passed to handlers
+// and adapter of context. middleware added first runs first
+func (d *Dispatcher) UseOutgoing(middlewares ...OutgoingMiddleware) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.outgoing = append(d.outgoing, middlewares...)
+}
+
+// withIncoming is wrap handler with incoming middlewares
+func (d *Dispatcher) withIncoming(handler ChatBotMessageHandler) ChatBotMessageHandler {
+	d.mu.RLock()
+	middlewares := d.incoming
+	d.mu.RUnlock()
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i](handler)
+	}
+	return handler
+}
+
+// Adapter is wrap chat adapter so that posts pass through outgoing middlewares
+func (d *Dispatcher) Adapter(adapter ChatAdapter) ChatAdapter {
+	if adapter == nil {
+		return nil
+	}
+	if wrapped, ok := adapter.(*middlewareAdapter); ok && wrapped.dispatcher == d {
+		return adapter
+	}
+	return &middlewareAdapter{ChatAdapter: adapter, dispatcher: d}
+}
+
+// middlewareAdapter is chat adapter running outgoing middlewares of dispatcher
+type middlewareAdapter struct {
+	ChatAdapter
+	dispatcher *Dispatcher
+}
+
+func (a *middlewareAdapter) post(post *OutgoingPost) (ChatMessageRef, error) {
+	send := func(post *OutgoingPost) (ChatMessageRef, error) {
+		switch post.Kind {
+		case PostKindThread:
+			return a.ChatAdapter.PostThreadMessage(post.Channel, post.ThreadID, post.Message)
+		case PostKindEphemeral:
+			return ChatMessageRef{}, a.ChatAdapter.PostEphemeralMessage(post.Channel, post.User, post.Message)
+		case PostKindDirect:
+			return a.ChatAdapter.PostDirectMessage(post.User, post.Message)
+		case PostKindUpdate:
+			return post.Ref, a.ChatAdapter.UpdateMessage(post.Ref, post.Message)
+		default:
+			return a.ChatAdapter.PostMessage(post.Channel, post.Message)
+		}
+	}
+	a.dispatcher.mu.RLock()
+	middlewares := a.dispatcher.outgoing
+	a.dispatcher.mu.RUnlock()
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		send = middlewares[i](send)
+	}
+	return send(post)
+}
+
+func (a *middlewareAdapter) PostTextMessage(channel, text string) (ChatMessageRef, error) {
+	return a.PostMessage(channel, &ChatMessage{Text: text})
+}
+
+func (a *middlewareAdapter) PostMessage(channel string, message *ChatMessage) (ChatMessageRef, error) {
+	return a.post(&OutgoingPost{Kind: PostKindMessage, Channel: channel, Message: message})
+}
+
+func (a *middlewareAdapter) PostThreadMessage(channel, threadID string, message *ChatMessage) (ChatMessageRef, error) {
+	return a.post(&OutgoingPost{Kind: PostKindThread, Channel: channel, ThreadID: threadID, Message: message})
+}
+
+func (a *middlewareAdapter) PostEphemeralMessage(channel, user string, message *ChatMessage) error {
+	_, err := a.post(&OutgoingPost{Kind: PostKindEphemeral, Channel: channel, User: user, Message: message})
+	return err
+}
+
+func (a *middlewareAdapter) PostDirectMessage(user string, message *ChatMessage) (ChatMessageRef, error) {
+	return a.post(&OutgoingPost{Kind: PostKindDirect, User: user, Message: message})
+}
+
+func (a *middlewareAdapter) UpdateMessage(ref ChatMessageRef, message *ChatMessage) error {
+	_, err := a.post(&OutgoingPost{Kind: PostKindUpdate, Channel: ref.Channel, Ref: ref, Message: message})
+	return err
+}
+
+// UnwrapChatAdapter is return wrapped adapter
+func (a *middlewareAdapter) UnwrapChatAdapter() ChatAdapter {
+	return a.ChatAdapter
+}
+
+// Close is close wrapped adapter
+func (a *middlewareAdapter) Close() error {
+	if closer, ok := a.ChatAdapter.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
@@ -1,6 +1,18 @@
 package glados
 
+import "context"
+
 // Program is glados system logic
 type Program interface {
 	Initialize(Context)
 }
+
+// Starter is optional Program interface called on Glados.Boot
+type Starter interface {
+	Start(Context) error
+}
+
+// Stopper is optional Program interface called on Glados.Shutdown
+type Stopper interface {
+	Stop(context.Context) error
+}
@@ -0,0 +1,191 @@
+package githubnotifier
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/astronoka/glados"
+	"github.com/google/go-github/github"
+)
+
+const (
+	deliveryNamespace = "githubnotifier.deliveries"
+	// deliveryIndexKey is key of delivery ids ordered by received time
+	deliveryIndexKey = "index"
+	// maxDeliveries is number of deliveries kept for replay
+	maxDeliveries = 1000
+)
+
+// defaultDeliveryTTL is how long delivery is kept to skip duplicates and replay
+const defaultDeliveryTTL = 72 * time.Hour
+
+// webhookDelivery is received webhook request identified by X-GitHub-Delivery header
+type webhookDelivery struct {
+	ID          string    `json:"id"`
+	EventType   string    `json:"event_type"`
+	Destination string    `json:"destination"`
+	Payload     string    `json:"payload,omitempty"`
+	ReceivedAt  time.Time `json:"received_at"`
+}
+
+func deliveryKey(id string) string {
+	return "delivery:" + id
+}
+
+// deliveryMutex serializes duplicate check and index update of deliveries
+var deliveryMutex sync.Mutex
+
+func deliveryTTL(context glados.Context) time.Duration {
+	ttl, err := time.ParseDuration(context.Env("GLADOS_GITHUB_NOTIFIER_DELIVERY_TTL", defaultDeliveryTTL.String()))
+	if err != nil || ttl <= 0 {
+		return defaultDeliveryTTL
+	}
+	return ttl
+}
+
+// recordDelivery is save delivery, and return false if it was already received within TTL
+func recordDelivery(context glados.Context, delivery *webhookDelivery) (bool, error) {
+	deliveryMutex.Lock()
+	defer deliveryMutex.Unlock()
+	storage := context.Storage()
+	ttl := deliveryTTL(context)
+	received := webhookDelivery{}
+	exist, err := storage.Load(deliveryNamespace, deliveryKey(delivery.ID), &received)
+	if err != nil {
+		return false, err
+	}
+	if exist && delivery.ReceivedAt.Sub(received.ReceivedAt) < ttl {
+		return false, nil
+	}
+	if err := storage.Save(deliveryNamespace, deliveryKey(delivery.ID), delivery); err != nil {
+		return false, err
+	}
+
+	var ids []string
+	if _, err := storage.Load(deliveryNamespace, deliveryIndexKey, &ids); err != nil {
+		return true, err
+	}
+	index := []string{}
+	for _, id := range ids {
+		// delivery received again after TTL moves to the end
+		if id != delivery.ID {
+			index = append(index, id)
+		}
+	}
+	index = append(index, delivery.ID)
+	// drop expired or overflowed deliveries from the oldest
+	for len(index) > 1 {
+		oldest := webhookDelivery{}
+		exist, err := storage.Load(deliveryNamespace, deliveryKey(index[0]), &oldest)
+		if err != nil {
+			return true, err
+		}
+		if exist && len(index) <= maxDeliveries && delivery.ReceivedAt.Sub(oldest.ReceivedAt) < ttl {
+			break
+		}
+		if err := storage.Delete(deliveryNamespace, deliveryKey(index[0])); err != nil {
+			return true, err
+		}
+		index = index[1:]
+	}
+	return true, storage.Save(deliveryNamespace, deliveryIndexKey, index)
+}
+
+func forgetDelivery(context glados.Context, id string) {
+	deliveryMutex.Lock()
+	defer deliveryMutex.Unlock()
+	if err := context.Storage().Delete(deliveryNamespace, deliveryKey(id)); err != nil {
+		context.Logger().Warnln("githubnotifier: delete delivery failed. " + err.Error())
+	}
+}
+
+func loadDeliveries(context glados.Context) ([]webhookDelivery, error) {
+	deliveryMutex.Lock()
+	defer deliveryMutex.Unlock()
+	var ids []string
+	if _, err := context.Storage().Load(deliveryNamespace, deliveryIndexKey, &ids); err != nil {
+		return nil, err
+	}
+	deliveries := []webhookDelivery{}
+	for i := len(ids) - 1; i >= 0; i-- {
+		delivery := webhookDelivery{}
+		exist, err := context.Storage().Load(deliveryNamespace, deliveryKey(ids[i]), &delivery)
+		if err != nil {
+			return nil, err
+		}
+		if exist {
+			deliveries = append(deliveries, delivery)
+		}
+	}
+	return deliveries, nil
+}
+
+// ListDeliveries is create request handler listing recent deliveries, newest first
+func ListDeliveries(context glados.Context) glados.RequestHandler {
+	return func(rc glados.RequestContext) {
+		deliveries, err := loadDeliveries(context)
+		if err != nil {
+			context.Logger().Warnln("githubnotifier: load deliveries failed. " + err.Error())
+			rc.JSON(http.StatusInternalServerError, glados.H{
+				"message": "githubnotifier: load deliveries failed",
+			})
+			return
+		}
+		for i := range deliveries {
+			deliveries[i].Payload = ""
+		}
+		rc.JSON(http.StatusOK, glados.H{
+			"deliveries": deliveries,
+		})
+	}
+}
+
+// ReplayDelivery is create request handler notifying stored delivery again.
+// destination query parameter overrides destination of the delivery
+func ReplayDelivery(context glados.Context, converter GitHubEventConverter) glados.RequestHandler {
+	return func(rc glados.RequestContext) {
+		delivery := webhookDelivery{}
+		exist, err := context.Storage().Load(deliveryNamespace, deliveryKey(rc.Param("id")), &delivery)
+		if err != nil {
+			context.Logger().Warnln("githubnotifier: load delivery failed. " + err.Error())
+			rc.JSON(http.StatusInternalServerError, glados.H{
+				"message": "githubnotifier: load delivery failed",
+			})
+			return
+		}
+		if !exist {
+			rc.JSON(http.StatusNotFound, glados.H{
+				"message": "githubnotifier: delivery not found",
+			})
+			return
+		}
+		event, err := github.ParseWebHook(delivery.EventType, []byte(delivery.Payload))
+		if err != nil {
+			rc.JSON(http.StatusUnprocessableEntity, glados.H{
+				"message": "githubnotifier: " + err.Error(),
+			})
+			return
+		}
+		destination := delivery.Destination
+		if d := rc.Request().URL.Query().Get("destination"); d != "" {
+			destination = d
+		}
+		context.Audit().Record(glados.AuditEntry{
+			Kind:      glados.AuditPrivileged,
+			Channel:   destination,
+			Handler:   "github delivery replay",
+			Arguments: delivery.ID,
+			Detail:    "from " + rc.Request().RemoteAddr,
+		})
+		if err := notifyEventToChatAdapter(context, destination, event, converter); err != nil {
+			rc.JSON(http.StatusBadGateway, glados.H{
+				"message": "githubnotifier: " + err.Error(),
+			})
+			return
+		}
+		rc.JSON(http.StatusOK, glados.H{
+			"message": "ok",
+		})
+	}
+}
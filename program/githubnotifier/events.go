@@ -0,0 +1,139 @@
+package githubnotifier
+
+import (
+	"strings"
+
+	"github.com/astronoka/glados"
+	"github.com/google/go-github/github"
+)
+
+const (
+	colorDefault = "#000000"
+	colorInfo    = "#0366d6"
+	colorSuccess = "#28a745"
+	colorWarning = "#dbab09"
+	colorFailure = "#cb2431"
+	colorMerged  = "#6f42c1"
+	colorNeutral = "#959da5"
+)
+
+var colorNames = map[string]string{
+	"default": colorDefault,
+	"info":    colorInfo,
+	"success": colorSuccess,
+	"warning": colorWarning,
+	"failure": colorFailure,
+	"merged":  colorMerged,
+	"neutral": colorNeutral,
+}
+
+var stateColors = map[string]string{
+	"success":           colorSuccess,
+	"failure":           colorFailure,
+	"error":             colorFailure,
+	"timed_out":         colorFailure,
+	"cancelled":         colorNeutral,
+	"neutral":           colorNeutral,
+	"action_required":   colorWarning,
+	"approved":          colorSuccess,
+	"changes_requested": colorFailure,
+	"commented":         colorNeutral,
+	"open":              colorSuccess,
+	"opened":            colorSuccess,
+	"reopened":          colorSuccess,
+	"merged":            colorMerged,
+	"closed":            colorFailure,
+}
+
+func stateColor(state string) string {
+	if color, exist := stateColors[strings.ToLower(state)]; exist {
+		return color
+	}
+	return colorDefault
+}
+
+var reviewStateLabels = map[string]string{
+	"approved":          "approved",
+	"changes_requested": "changes requested",
+	"commented":         "review commented",
+}
+
+func reviewStateLabel(state string) string {
+	return reviewStateLabels[strings.ToLower(state)]
+}
+
+func pullRequestState(pr *github.PullRequest) string {
+	if pr.GetMerged() {
+		return "merged"
+	}
+	if pr.GetState() == "closed" {
+		return "closed"
+	}
+	return "open"
+}
+
+func (p *program) buildAuthor(adapter string, user *github.User) glados.MessageAuthor {
+	return glados.MessageAuthor{
+		Name:    user.GetLogin(),
+		Subname: p.convertGithubName(adapter, user.GetLogin()),
+		Link:    user.GetHTMLURL(),
+		IconURL: user.GetAvatarURL(),
+	}
+}
+
+func shortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
+
+func firstLine(text string) string {
+	if i := strings.Index(text, "\n"); i >= 0 {
+		return text[:i]
+	}
+	return text
+}
+
+func commitAuthor(commit github.PushEventCommit) string {
+	if login := commit.GetAuthor().GetLogin(); login != "" {
+		return login
+	}
+	return commit.GetAuthor().GetName()
+}
+
+// notificationAuthor is return user shown as message author, and false if event is not notified
+func notificationAuthor(event interface{}) (*github.User, bool) {
+	switch event := event.(type) {
+	case *github.PullRequestEvent:
+		return event.GetPullRequest().GetUser(), true
+	case *github.IssueCommentEvent:
+		return event.GetSender(), event.GetAction() == "created"
+	case *github.PullRequestReviewCommentEvent:
+		return event.GetSender(), event.GetAction() == "created"
+	case *github.PullRequestReviewEvent:
+		return event.GetReview().GetUser(), event.GetAction() == "submitted" &&
+			reviewStateLabel(event.GetReview().GetState()) != ""
+	case *github.PushEvent:
+		return event.GetSender(), !event.GetDeleted() && len(event.Commits) > 0
+	case *github.IssuesEvent:
+		action := event.GetAction()
+		return event.GetSender(), action == "opened" || action == "closed" || action == "reopened"
+	case *github.ReleaseEvent:
+		return event.GetRelease().GetAuthor(), event.GetAction() == "published"
+	case *github.CreateEvent:
+		return event.GetSender(), event.GetRefType() == "branch" || event.GetRefType() == "tag"
+	case *github.DeleteEvent:
+		return event.GetSender(), true
+	case *github.StatusEvent:
+		return event.GetSender(), event.GetState() != "pending"
+	case *github.CheckRunEvent:
+		return event.GetSender(), event.GetAction() == "completed"
+	case *github.CheckSuiteEvent:
+		return event.GetSender(), event.GetAction() == "completed"
+	case *github.DeploymentStatusEvent:
+		state := event.GetDeploymentStatus().GetState()
+		return event.GetDeployment().GetCreator(), state == "success" || state == "failure" || state == "error"
+	}
+	return nil, false
+}
@@ -0,0 +1,498 @@
+package githubnotifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/astronoka/glados"
+	"github.com/google/go-github/github"
+)
+
+const ruleNamespace = "githubnotifier.rules"
+
+// GitHubEventFilter is optional interface deciding whether event is notified to destination.
+// error means it can not be decided now, and the event is retried
+type GitHubEventFilter interface {
+	FilterEvent(destination string, event interface{}) (bool, error)
+}
+
+// FilterRule is notification rule of destination.
+// every non-empty field must match, and a field matches when any of its glob patterns matches.
+// the destination receives event when any of its rules matches, or when it has no rule.
+type FilterRule struct {
+	Repositories []string `json:"repositories,omitempty"`
+	Events       []string `json:"events,omitempty"`
+	Actions      []string `json:"actions,omitempty"`
+	Branches     []string `json:"branches,omitempty"`
+	Labels       []string `json:"labels,omitempty"`
+	Authors      []string `json:"authors,omitempty"`
+	Paths        []string `json:"paths,omitempty"`
+}
+
+func (r FilterRule) String() string {
+	var fields []string
+	add := func(name string, patterns []string) {
+		if len(patterns) > 0 {
+			fields = append(fields, "--"+name+"="+strings.Join(patterns, ","))
+		}
+	}
+	add("repo", r.Repositories)
+	add("event", r.Events)
+	add("action", r.Actions)
+	add("branch", r.Branches)
+	add("label", r.Labels)
+	add("author", r.Authors)
+	add("path", r.Paths)
+	if len(fields) <= 0 {
+		return "(all events)"
+	}
+	return strings.Join(fields, " ")
+}
+
+// LoadFilterRules is read rules file, a JSON object of destination -> rules
+func LoadFilterRules(filename string) (map[string][]FilterRule, error) {
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	rules := map[string][]FilterRule{}
+	if err := json.Unmarshal(b, &rules); err != nil {
+		return nil, fmt.Errorf("%s: %s", filename, err.Error())
+	}
+	for destination, destinationRules := range rules {
+		for i, rule := range destinationRules {
+			if err := rule.validate(); err != nil {
+				return nil, fmt.Errorf("%s: %s rule %d: %s", filename, destination, i, err.Error())
+			}
+		}
+	}
+	return rules, nil
+}
+
+func (r FilterRule) validate() error {
+	for _, patterns := range [][]string{r.Repositories, r.Events, r.Actions, r.Branches, r.Labels, r.Authors, r.Paths} {
+		for _, pattern := range patterns {
+			if _, err := compileGlob(pattern); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// eventSubject is attributes of event which rules are matched against
+type eventSubject struct {
+	repository string
+	event      string
+	actions    []string
+	branches   []string
+	labels     []string
+	author     string
+	// paths is list changed files. it is called only if rule has paths
+	paths func() ([]string, error)
+}
+
+func (r FilterRule) match(subject *eventSubject) (bool, error) {
+	matched := matchAny(r.Repositories, subject.repository) &&
+		matchAny(r.Events, subject.event) &&
+		matchAnyOf(r.Actions, subject.actions) &&
+		matchAnyOf(r.Branches, subject.branches) &&
+		matchAnyOf(r.Labels, subject.labels) &&
+		matchAny(r.Authors, subject.author)
+	if !matched || len(r.Paths) <= 0 {
+		return matched, nil
+	}
+	paths, err := subject.changedPaths()
+	if err != nil {
+		return false, err
+	}
+	return matchAnyOf(r.Paths, paths), nil
+}
+
+// changedPaths is return changed files. successful result is cached for following rules
+func (s *eventSubject) changedPaths() ([]string, error) {
+	if s.paths == nil {
+		return nil, nil
+	}
+	paths, err := s.paths()
+	if err != nil {
+		return nil, err
+	}
+	s.paths = func() ([]string, error) { return paths, nil }
+	return paths, nil
+}
+
+func matchAny(patterns []string, value string) bool {
+	return matchAnyOf(patterns, []string{value})
+}
+
+func matchAnyOf(patterns []string, values []string) bool {
+	if len(patterns) <= 0 {
+		return true
+	}
+	for _, pattern := range patterns {
+		for _, value := range values {
+			if globMatch(pattern, value) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// compileGlob is convert glob to regexp. "*" and "?" does not match "/", "**" matches any path
+func compileGlob(pattern string) (*regexp.Regexp, error) {
+	pattern = strings.TrimPrefix(pattern, "/")
+	var buf bytes.Buffer
+	buf.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			buf.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**"):
+			buf.WriteString(".*")
+			i++
+		case c == '*':
+			buf.WriteString("[^/]*")
+		case c == '?':
+			buf.WriteString("[^/]")
+		default:
+			buf.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	buf.WriteString("$")
+	return regexp.Compile(buf.String())
+}
+
+func globMatch(pattern, value string) bool {
+	r, err := compileGlob(pattern)
+	if err != nil {
+		return false
+	}
+	return r.MatchString(strings.TrimPrefix(value, "/"))
+}
+
+// ruleSet is rules of destinations. rules saved in storage take precedence over rules file
+type ruleSet struct {
+	mu      sync.Mutex
+	context glados.Context
+	file    map[string][]FilterRule
+}
+
+func (s *ruleSet) rules(destination string) []FilterRule {
+	rules := []FilterRule{}
+	exist, err := s.context.Storage().Load(ruleNamespace, destination, &rules)
+	if err != nil {
+		s.context.Logger().Warnln("githubnotifier: load rules failed. " + err.Error())
+	}
+	if exist {
+		return rules
+	}
+	return s.file[destination]
+}
+
+func (s *ruleSet) update(destination string, f func([]FilterRule) ([]FilterRule, error)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rules, err := f(append([]FilterRule{}, s.rules(destination)...))
+	if err != nil {
+		return err
+	}
+	return s.context.Storage().Save(ruleNamespace, destination, rules)
+}
+
+func (s *ruleSet) reset(destination string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.context.Storage().Delete(ruleNamespace, destination)
+}
+
+// FilterEvent is implement GitHubEventFilter
+func (p *program) FilterEvent(destination string, event interface{}) (bool, error) {
+	rules := p.rules.rules(destination)
+	if len(rules) <= 0 {
+		return true, nil
+	}
+	subject := p.buildEventSubject(event)
+	if subject == nil {
+		return false, nil
+	}
+	for _, rule := range rules {
+		matched, err := rule.match(subject)
+		if err != nil {
+			return false, err
+		}
+		if matched {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func labelNames(labels []github.Label) []string {
+	var names []string
+	for _, label := range labels {
+		names = append(names, label.GetName())
+	}
+	return names
+}
+
+func (p *program) buildEventSubject(event interface{}) *eventSubject {
+	switch event := event.(type) {
+	case *github.PullRequestEvent:
+		pr := event.GetPullRequest()
+		actions := []string{event.GetAction()}
+		if event.GetAction() == "closed" && pr.GetMerged() {
+			actions = append(actions, "merged")
+		}
+		var labels []string
+		for _, label := range pr.Labels {
+			labels = append(labels, label.GetName())
+		}
+		return &eventSubject{
+			repository: event.GetRepo().GetFullName(),
+			event:      "pull_request",
+			actions:    actions,
+			branches:   []string{pr.GetBase().GetRef()},
+			labels:     labels,
+			author:     pr.GetUser().GetLogin(),
+			paths:      p.pullRequestFiles(event.GetRepo(), pr.GetNumber()),
+		}
+	case *github.PullRequestReviewEvent:
+		pr := event.GetPullRequest()
+		return &eventSubject{
+			repository: event.GetRepo().GetFullName(),
+			event:      "pull_request_review",
+			actions:    []string{event.GetAction()},
+			branches:   []string{pr.GetBase().GetRef()},
+			author:     event.GetReview().GetUser().GetLogin(),
+			paths:      p.pullRequestFiles(event.GetRepo(), pr.GetNumber()),
+		}
+	case *github.PullRequestReviewCommentEvent:
+		pr := event.GetPullRequest()
+		return &eventSubject{
+			repository: event.GetRepo().GetFullName(),
+			event:      "pull_request_review_comment",
+			actions:    []string{event.GetAction()},
+			branches:   []string{pr.GetBase().GetRef()},
+			author:     event.GetSender().GetLogin(),
+			paths: func() ([]string, error) {
+				return []string{event.GetComment().GetPath()}, nil
+			},
+		}
+	case *github.IssueCommentEvent:
+		subject := &eventSubject{
+			repository: event.GetRepo().GetFullName(),
+			event:      "issue_comment",
+			actions:    []string{event.GetAction()},
+			labels:     labelNames(event.GetIssue().Labels),
+			author:     event.GetSender().GetLogin(),
+		}
+		if event.GetIssue().GetPullRequestLinks() != nil {
+			subject.paths = p.pullRequestFiles(event.GetRepo(), event.GetIssue().GetNumber())
+		}
+		return subject
+	case *github.IssuesEvent:
+		return &eventSubject{
+			repository: event.GetRepo().GetFullName(),
+			event:      "issues",
+			actions:    []string{event.GetAction()},
+			labels:     labelNames(event.GetIssue().Labels),
+			author:     event.GetIssue().GetUser().GetLogin(),
+		}
+	case *github.PushEvent:
+		return &eventSubject{
+			repository: event.GetRepo().GetFullName(),
+			event:      "push",
+			branches:   []string{strings.TrimPrefix(event.GetRef(), "refs/heads/")},
+			author:     event.GetPusher().GetName(),
+			paths: func() ([]string, error) {
+				var paths []string
+				for _, commit := range event.Commits {
+					paths = append(paths, commit.Added...)
+					paths = append(paths, commit.Removed...)
+					paths = append(paths, commit.Modified...)
+				}
+				return paths, nil
+			},
+		}
+	case *github.ReleaseEvent:
+		return &eventSubject{
+			repository: event.GetRepo().GetFullName(),
+			event:      "release",
+			actions:    []string{event.GetAction()},
+			branches:   []string{event.GetRelease().GetTargetCommitish()},
+			author:     event.GetRelease().GetAuthor().GetLogin(),
+		}
+	case *github.CreateEvent:
+		return &eventSubject{
+			repository: event.GetRepo().GetFullName(),
+			event:      "create",
+			actions:    []string{event.GetRefType()},
+			branches:   []string{event.GetRef()},
+			author:     event.GetSender().GetLogin(),
+		}
+	case *github.DeleteEvent:
+		return &eventSubject{
+			repository: event.GetRepo().GetFullName(),
+			event:      "delete",
+			actions:    []string{event.GetRefType()},
+			branches:   []string{event.GetRef()},
+			author:     event.GetSender().GetLogin(),
+		}
+	case *github.StatusEvent:
+		var branches []string
+		for _, branch := range event.Branches {
+			branches = append(branches, branch.GetName())
+		}
+		return &eventSubject{
+			repository: event.GetRepo().GetFullName(),
+			event:      "status",
+			actions:    []string{event.GetState()},
+			branches:   branches,
+			author:     event.GetSender().GetLogin(),
+		}
+	case *github.CheckRunEvent:
+		return &eventSubject{
+			repository: event.GetRepo().GetFullName(),
+			event:      "check_run",
+			actions:    []string{event.GetAction(), event.GetCheckRun().GetConclusion()},
+			branches:   []string{event.GetCheckRun().GetCheckSuite().GetHeadBranch()},
+			author:     event.GetSender().GetLogin(),
+		}
+	case *github.CheckSuiteEvent:
+		return &eventSubject{
+			repository: event.GetRepo().GetFullName(),
+			event:      "check_suite",
+			actions:    []string{event.GetAction(), event.GetCheckSuite().GetConclusion()},
+			branches:   []string{event.GetCheckSuite().GetHeadBranch()},
+			author:     event.GetSender().GetLogin(),
+		}
+	case *github.DeploymentStatusEvent:
+		return &eventSubject{
+			repository: event.GetRepo().GetFullName(),
+			event:      "deployment_status",
+			actions:    []string{event.GetDeploymentStatus().GetState()},
+			branches:   []string{event.GetDeployment().GetRef()},
+			author:     event.GetDeployment().GetCreator().GetLogin(),
+		}
+	}
+	return nil
+}
+
+// maxPullRequestFilePages is limit of pull request files pages fetched for path rules
+const maxPullRequestFilePages = 30
+
+// pullRequestFiles is return func listing changed files of pull request.
+// failure is returned so that event is retried instead of dropped by path rules
+func (p *program) pullRequestFiles(repo *github.Repository, number int) func() ([]string, error) {
+	return func() ([]string, error) {
+		var paths []string
+		opt := &github.ListOptions{PerPage: 100}
+		for page := 0; page < maxPullRequestFilePages; page++ {
+			files, res, err := p.client.PullRequests.ListFiles(context.Background(),
+				repo.GetOwner().GetLogin(), repo.GetName(), number, opt)
+			if err != nil {
+				return nil, errors.New("githubnotifier: list pull request files failed. " + err.Error())
+			}
+			for _, file := range files {
+				paths = append(paths, file.GetFilename())
+			}
+			if res.NextPage == 0 {
+				break
+			}
+			opt.Page = res.NextPage
+		}
+		return paths, nil
+	}
+}
+
+// ruleFlags is chat command flags of rule fields. each flag takes comma separated glob patterns
+const ruleFlags = "--repo= --event= --action= --branch= --label= --author= --path="
+
+func (p *program) listRules(adapter glados.ChatAdapter, message *glados.ChatMessageEvent, args *glados.CommandArgs) {
+	destination := args.String("destination")
+	rules := p.rules.rules(destination)
+	if len(rules) <= 0 {
+		p.say(adapter, message.Channel, destination+" has no rule. all events are notified")
+		return
+	}
+	lines := []string{destination + " rules:"}
+	for i, rule := range rules {
+		lines = append(lines, fmt.Sprintf("%d: %s", i+1, rule))
+	}
+	p.say(adapter, message.Channel, strings.Join(lines, "\n"))
+}
+
+func splitPatterns(value string) []string {
+	var patterns []string
+	for _, pattern := range strings.Split(value, ",") {
+		if pattern = strings.TrimSpace(pattern); pattern != "" {
+			patterns = append(patterns, pattern)
+		}
+	}
+	return patterns
+}
+
+func (p *program) addRule(adapter glados.ChatAdapter, message *glados.ChatMessageEvent, args *glados.CommandArgs) {
+	destination := args.String("destination")
+	rule := FilterRule{
+		Repositories: splitPatterns(args.String("repo")),
+		Events:       splitPatterns(args.String("event")),
+		Actions:      splitPatterns(args.String("action")),
+		Branches:     splitPatterns(args.String("branch")),
+		Labels:       splitPatterns(args.String("label")),
+		Authors:      splitPatterns(args.String("author")),
+		Paths:        splitPatterns(args.String("path")),
+	}
+	if err := rule.validate(); err != nil {
+		p.say(adapter, message.Channel, "@"+message.User+" "+err.Error())
+		return
+	}
+	err := p.rules.update(destination, func(rules []FilterRule) ([]FilterRule, error) {
+		return append(rules, rule), nil
+	})
+	if err != nil {
+		p.context.Logger().Warnln("githubnotifier: save rules failed. " + err.Error())
+		p.say(adapter, message.Channel, "@"+message.User+" save rule failed")
+		return
+	}
+	p.auditChange(message, "github rule add", destination+" "+rule.String())
+	p.say(adapter, message.Channel, "@"+message.User+" added "+destination+" rule: "+rule.String())
+}
+
+func (p *program) removeRule(adapter glados.ChatAdapter, message *glados.ChatMessageEvent, args *glados.CommandArgs) {
+	destination := args.String("destination")
+	index := args.Int("index")
+	err := p.rules.update(destination, func(rules []FilterRule) ([]FilterRule, error) {
+		if index < 1 || index > len(rules) {
+			return nil, fmt.Errorf("%s has no rule %d", destination, index)
+		}
+		return append(rules[:index-1], rules[index:]...), nil
+	})
+	if err != nil {
+		p.say(adapter, message.Channel, "@"+message.User+" "+err.Error())
+		return
+	}
+	p.auditChange(message, "github rule remove", fmt.Sprintf("%s %d", destination, index))
+	p.say(adapter, message.Channel, fmt.Sprintf("@%s removed %s rule %d", message.User, destination, index))
+}
+
+func (p *program) resetRules(adapter glados.ChatAdapter, message *glados.ChatMessageEvent, args *glados.CommandArgs) {
+	destination := args.String("destination")
+	if err := p.rules.reset(destination); err != nil {
+		p.context.Logger().Warnln("githubnotifier: delete rules failed. " + err.Error())
+		p.say(adapter, message.Channel, "@"+message.User+" reset rules failed")
+		return
+	}
+	p.auditChange(message, "github rule reset", destination)
+	p.say(adapter, message.Channel, "@"+message.User+" "+destination+" rules are reset to rules file")
+}
@@ -0,0 +1,109 @@
+package githubnotifier
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestGlobMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		value   string
+		want    bool
+	}{
+		{"main", "main", true},
+		{"main", "main2", false},
+		{"release/*", "release/v1", true},
+		{"release/*", "release/v1/hotfix", false},
+		{"release/**", "release/v1/hotfix", true},
+		{"release/**", "release/", true},
+		{"v?.0", "v1.0", true},
+		{"v?.0", "v10.0", false},
+		{"v?.0", "v/.0", false},
+		{"astronoka/*", "astronoka/glados", true},
+		{"*/glados", "astronoka/glados", true},
+		{"*", "astronoka/glados", false},
+		{"**", "astronoka/glados", true},
+		{"/api/**", "api/v1/users.go", true},
+		{"/api/**", "/api/v1/users.go", true},
+		{"api/**", "web/api/v1.go", false},
+		{"**/*.go", "main.go", true},
+		{"**/*.go", "cmd/server/main.go", true},
+		{"**/*.go", "cmd/server/main.gox", false},
+		{"docs/**/*.md", "docs/README.md", true},
+		{"docs/**/*.md", "docs/a/b/c.md", true},
+		{"a.b", "axb", false},
+		{"[x]", "[x]", true},
+		{"(x)+", "(x)+", true},
+	}
+	for _, test := range tests {
+		if got := globMatch(test.pattern, test.value); got != test.want {
+			t.Errorf("globMatch(%q, %q) = %v, want %v", test.pattern, test.value, got, test.want)
+		}
+	}
+}
+
+func TestFilterRuleMatch(t *testing.T) {
+	subject := &eventSubject{
+		repository: "astronoka/glados",
+		event:      "pull_request",
+		actions:    []string{"closed", "merged"},
+		branches:   []string{"main"},
+		labels:     []string{"bug", "urgent"},
+		author:     "octocat",
+		paths:      func() ([]string, error) { return []string{"api/v1/users.go", "README.md"}, nil },
+	}
+	tests := []struct {
+		name string
+		rule FilterRule
+		want bool
+	}{
+		{"empty rule", FilterRule{}, true},
+		{"every field", FilterRule{
+			Repositories: []string{"astronoka/*"},
+			Events:       []string{"pull_request"},
+			Actions:      []string{"merged"},
+			Branches:     []string{"main"},
+			Labels:       []string{"urgent"},
+			Authors:      []string{"octo*"},
+			Paths:        []string{"/api/**"},
+		}, true},
+		{"any pattern of field", FilterRule{Events: []string{"push", "pull_request"}}, true},
+		{"other repository", FilterRule{Repositories: []string{"other/*"}}, false},
+		{"other action", FilterRule{Actions: []string{"opened"}}, false},
+		{"one field mismatch", FilterRule{Events: []string{"pull_request"}, Branches: []string{"develop"}}, false},
+		{"unchanged path", FilterRule{Paths: []string{"web/**"}}, false},
+	}
+	for _, test := range tests {
+		got, err := test.rule.match(subject)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != test.want {
+			t.Errorf("%s: match = %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestFilterRuleMatchPathsError(t *testing.T) {
+	subject := &eventSubject{
+		event: "pull_request",
+		paths: func() ([]string, error) { return nil, errors.New("api is down") },
+	}
+	tests := []struct {
+		name    string
+		rule    FilterRule
+		want    bool
+		wantErr bool
+	}{
+		{"rule without paths", FilterRule{Events: []string{"pull_request"}}, true, false},
+		{"other event with paths", FilterRule{Events: []string{"push"}, Paths: []string{"/api/**"}}, false, false},
+		{"rule with paths", FilterRule{Paths: []string{"/api/**"}}, false, true},
+	}
+	for _, test := range tests {
+		got, err := test.rule.match(subject)
+		if got != test.want || (err != nil) != test.wantErr {
+			t.Errorf("%s: match = %v, %v, want %v, error %v", test.name, got, err, test.want, test.wantErr)
+		}
+	}
+}
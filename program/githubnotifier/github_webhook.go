@@ -3,8 +3,11 @@ package githubnotifier
 import (
 	"crypto/rand"
 	"encoding/binary"
+	"errors"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/astronoka/glados"
 	"github.com/google/go-github/github"
@@ -15,49 +18,162 @@ type GitHubEventConverter interface {
 	ConvertEventToChatMessage(event interface{}) *glados.ChatMessage
 }
 
-// NotifyEvent is create request handler
+// GitHubDestinationConverter is optional converter interface translating event to message for destination
+type GitHubDestinationConverter interface {
+	ConvertEventToDestinationMessage(destination string, event interface{}) *glados.ChatMessage
+}
+
+// NotifyEvent is create request handler notifying event before response.
+// deliveries are recorded by X-GitHub-Delivery header, and duplicated delivery is skipped
 func NotifyEvent(context glados.Context, converter GitHubEventConverter, secret string) glados.RequestHandler {
 	return func(rc glados.RequestContext) {
-		event, status, message := buildEventFromRequest(context, rc, secret)
+		delivery, event := receiveEvent(context, rc, secret)
 		if event == nil {
-			rc.JSON(status, glados.H{
-				"message": message,
-			})
 			return
 		}
-
-		destination := rc.Param("destination")
-		notifyEventToChatAdapter(context, destination, event, converter)
+		if err := notifyEventToChatAdapter(context, delivery.Destination, event, converter); err != nil {
+			context.Logger().Warnln("githubnotifier: notify event failed. " + err.Error())
+		}
 		rc.JSON(http.StatusOK, glados.H{
 			"message": "ok",
 		})
 	}
 }
 
-func buildEventFromRequest(context glados.Context, rc glados.RequestContext, secret string) (interface{}, int, string) {
+// EnqueueEvent is create request handler adding event to queue and responding 202
+func EnqueueEvent(context glados.Context, queue *EventQueue, secret string) glados.RequestHandler {
+	return func(rc glados.RequestContext) {
+		delivery, event := receiveEvent(context, rc, secret)
+		if event == nil {
+			return
+		}
+		if err := queue.enqueue(delivery); err != nil {
+			context.Logger().Warnln("githubnotifier: enqueue event failed. " + err.Error())
+			if delivery.ID != "" {
+				// let redelivery of github through duplicate check
+				forgetDelivery(context, delivery.ID)
+			}
+			rc.JSON(http.StatusInternalServerError, glados.H{
+				"message": "githubnotifier: enqueue event failed",
+			})
+			return
+		}
+		rc.JSON(http.StatusAccepted, glados.H{
+			"message": "accepted",
+		})
+	}
+}
+
+// receiveEvent is validate request and record delivery.
+// event is nil if it should not be notified, and response is already written
+func receiveEvent(context glados.Context, rc glados.RequestContext, secret string) (*webhookDelivery, interface{}) {
+	delivery, event, status, message := buildEventFromRequest(context, rc, secret)
+	if event == nil {
+		rc.JSON(status, glados.H{
+			"message": message,
+		})
+		return nil, nil
+	}
+	delivery.Destination = rc.Param("destination")
+	if delivery.ID != "" {
+		first, err := recordDelivery(context, delivery)
+		if err != nil {
+			context.Logger().Warnln("githubnotifier: record delivery failed. " + err.Error())
+		} else if !first {
+			auditWebhook(context, delivery, "duplicated")
+			context.Logger().Infoln("githubnotifier: skip duplicated delivery " + delivery.ID)
+			rc.JSON(http.StatusOK, glados.H{
+				"message": "duplicated delivery",
+			})
+			return nil, nil
+		}
+	}
+	auditWebhook(context, delivery, "")
+	return delivery, event
+}
+
+func auditWebhook(context glados.Context, delivery *webhookDelivery, detail string) {
+	context.Audit().Record(glados.AuditEntry{
+		Kind:      glados.AuditWebhook,
+		Channel:   delivery.Destination,
+		Handler:   "github " + delivery.EventType,
+		Arguments: delivery.ID,
+		Detail:    detail,
+	})
+}
+
+func buildEventFromRequest(context glados.Context, rc glados.RequestContext, secret string) (*webhookDelivery, interface{}, int, string) {
 	payload, err := github.ValidatePayload(rc.Request(), []byte(secret))
 	if err != nil {
-		return nil, http.StatusBadRequest, "githubnotifier: validate payload failed:" + err.Error()
+		return nil, nil, http.StatusBadRequest, "githubnotifier: validate payload failed:" + err.Error()
 	}
 
 	eventType := github.WebHookType(rc.Request())
 	event, err := github.ParseWebHook(eventType, payload)
 	if err != nil {
 		context.Logger().Infoln("githubnotifier: " + err.Error())
-		return nil, http.StatusAccepted, "githubnotifier: unsupported event"
+		return nil, nil, http.StatusAccepted, "githubnotifier: unsupported event"
 	}
 
 	if eventType == "ping" {
-		return nil, http.StatusOK, "ok"
+		return nil, nil, http.StatusOK, "ok"
+	}
+	delivery := &webhookDelivery{
+		ID:         github.DeliveryID(rc.Request()),
+		EventType:  eventType,
+		Payload:    string(payload),
+		ReceivedAt: time.Now(),
+	}
+	return delivery, event, http.StatusOK, "ok"
+}
+
+func notifyEventToChatAdapter(context glados.Context, destination string, event interface{}, converter GitHubEventConverter) error {
+	if filter, ok := converter.(GitHubEventFilter); ok {
+		notify, err := filter.FilterEvent(destination, event)
+		if err != nil || !notify {
+			return err
+		}
+	}
+	var message *glados.ChatMessage
+	if destinationConverter, ok := converter.(GitHubDestinationConverter); ok {
+		message = destinationConverter.ConvertEventToDestinationMessage(destination, event)
+	} else {
+		message = converter.ConvertEventToChatMessage(event)
+	}
+	if message == nil {
+		return nil
+	}
+	if key, ok := pullRequestThreadKey(event); ok {
+		return notifyEventToThread(context, destination, key, event, message, converter)
+	}
+	adapter, channel, err := destinationChatAdapter(context, destination)
+	if err != nil {
+		return err
+	}
+	_, err = adapter.PostMessage(channel, message)
+	return err
+}
+
+// destinationChatAdapter is return chat adapter and channel of destination "adapter:channel".
+// destination without adapter name is channel of default chat adapter
+func destinationChatAdapter(context glados.Context, destination string) (glados.ChatAdapter, string, error) {
+	name, channel := destinationAdapterName(destination), destination
+	if name != "" {
+		channel = destination[len(name)+1:]
+	}
+	adapter := context.NamedChatAdapter(name)
+	if adapter == nil {
+		return nil, "", errors.New("githubnotifier: chat adapter of destination " + destination + " is not found")
 	}
-	return event, http.StatusOK, "ok"
+	return adapter, channel, nil
 }
 
-func notifyEventToChatAdapter(context glados.Context, destination string, event interface{}, converter GitHubEventConverter) {
-	message := converter.ConvertEventToChatMessage(event)
-	if message != nil {
-		context.ChatAdapter().PostMessage(destination, message)
+// destinationAdapterName is return adapter name of destination "adapter:channel", or empty for default adapter
+func destinationAdapterName(destination string) string {
+	if i := strings.Index(destination, ":"); i >= 0 {
+		return destination[:i]
 	}
+	return ""
 }
 
 func random() string {
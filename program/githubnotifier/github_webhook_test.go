@@ -0,0 +1,173 @@
+package githubnotifier_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/astronoka/glados/gladostest"
+	"github.com/astronoka/glados/program/githubnotifier"
+)
+
+const testSecret = "s3cret"
+
+const pullRequestPayload = `{
+		"action": "opened",
+		"number": 7,
+		"pull_request": {
+			"number": 7, "state": "open", "title": "Fix typo", "body": "please review @octocat",
+			"html_url": "https://github.com/astronoka/glados/pull/7",
+			"base": {"ref": "main"},
+			"user": {"login": "octocat", "html_url": "https://github.com/octocat", "avatar_url": "https://github.com/octocat.png"}
+		},
+		"repository": {"full_name": "astronoka/glados"},
+		"sender": {"login": "octocat", "html_url": "https://github.com/octocat", "avatar_url": "https://github.com/octocat.png"}
+	}`
+
+const issueCommentPayload = `{
+	"action": "created",
+	"issue": {"number": 7, "pull_request": {"url": "https://api.github.com/repos/astronoka/glados/pulls/7"}, "user": {"login": "octocat"}},
+	"comment": {"body": "LGTM", "html_url": "https://github.com/astronoka/glados/pull/7#issuecomment-1"},
+	"repository": {"full_name": "astronoka/glados"},
+	"sender": {"login": "hubot", "html_url": "https://github.com/hubot", "avatar_url": "https://github.com/hubot.png"}
+}`
+
+func newHarness(names map[string]string) *gladostest.Harness {
+	os.Setenv("GLADOS_GITHUB_NOTIFIER_SECRET", testSecret)
+	h := gladostest.New()
+	h.Install(githubnotifier.NewProgram(names))
+	h.Boot()
+	return h
+}
+
+func TestNotifySignedWebhook(t *testing.T) {
+	h := newHarness(map[string]string{"octocat": "octo"})
+	defer h.Shutdown()
+
+	res := h.Router.Do(gladostest.NewGitHubWebhookRequest("/github/notify_events/dev", "pull_request", testSecret, []byte(pullRequestPayload)))
+	if res.Code != http.StatusAccepted {
+		t.Fatalf("status = %d, body = %s", res.Code, res.Body.String())
+	}
+	messages, ok := h.ChatAdapter.WaitMessages(1, 3*time.Second)
+	if !ok {
+		t.Fatalf("no message is posted. %v", h.Logger.Lines())
+	}
+	root := messages[0]
+	if root.Channel != "dev" || root.ThreadID != "" || root.Message == nil {
+		t.Fatalf("unexpected root message %+v", root)
+	}
+	if !strings.Contains(root.Message.Title, "Fix typo") {
+		t.Errorf("title = %q", root.Message.Title)
+	}
+	if !strings.Contains(root.Message.Text, "@octo") {
+		t.Errorf("github name is not converted. text = %q", root.Message.Text)
+	}
+
+	h.Router.Do(gladostest.NewGitHubWebhookRequest("/github/notify_events/dev", "issue_comment", testSecret, []byte(issueCommentPayload)))
+	messages, ok = h.ChatAdapter.WaitMessages(2, 3*time.Second)
+	if !ok {
+		t.Fatalf("no thread reply is posted. %v", h.Logger.Lines())
+	}
+	reply := messages[1]
+	if reply.Channel != "dev" || reply.ThreadID != root.MessageID {
+		t.Fatalf("reply is not in thread of %s: %+v", root.MessageID, reply)
+	}
+	if !strings.Contains(reply.Message.Text, "LGTM") {
+		t.Errorf("reply text = %q", reply.Message.Text)
+	}
+}
+
+func TestRejectBadSignature(t *testing.T) {
+	h := newHarness(nil)
+	defer h.Shutdown()
+
+	res := h.Router.Do(gladostest.NewGitHubWebhookRequest("/github/notify_events/dev", "pull_request", "wrong", []byte(pullRequestPayload)))
+	if res.Code == http.StatusAccepted || res.Code == http.StatusOK {
+		t.Fatalf("status = %d", res.Code)
+	}
+	if _, ok := h.ChatAdapter.WaitMessages(1, 200*time.Millisecond); ok {
+		t.Fatal("message is posted for bad signature")
+	}
+}
+
+func TestThreadRootIsPostedBeforeComment(t *testing.T) {
+	h := newHarness(nil)
+	defer h.Shutdown()
+
+	h.Router.Do(gladostest.NewGitHubWebhookRequest("/github/notify_events/dev", "issue_comment", testSecret, []byte(issueCommentPayload)))
+	messages, ok := h.ChatAdapter.WaitMessages(2, 3*time.Second)
+	if !ok {
+		t.Fatalf("root and comment are not posted. %+v %v", messages, h.Logger.Lines())
+	}
+	root, comment := messages[0], messages[1]
+	if root.ThreadID != "" || strings.Contains(root.Message.Text, "LGTM") {
+		t.Fatalf("comment is posted as thread root: %+v", root.Message)
+	}
+	if comment.ThreadID != root.MessageID || !strings.Contains(comment.Message.Text, "LGTM") {
+		t.Fatalf("comment is not replied in thread of %s: %+v", root.MessageID, comment)
+	}
+
+	h.Router.Do(gladostest.NewGitHubWebhookRequest("/github/notify_events/dev", "pull_request", testSecret, []byte(pullRequestPayload)))
+	messages, ok = h.ChatAdapter.WaitMessages(4, 3*time.Second)
+	if !ok {
+		t.Fatalf("pull request is not notified. %+v %v", messages, h.Logger.Lines())
+	}
+	for _, m := range messages[2:] {
+		if m.Updated && m.MessageID != root.MessageID {
+			t.Errorf("message %s other than root is updated: %+v", m.MessageID, m.Message)
+		}
+		if m.Updated && !strings.Contains(m.Message.Title, "Fix typo") {
+			t.Errorf("root is not updated with pull request: %+v", m.Message)
+		}
+		if !m.Updated && m.ThreadID != root.MessageID {
+			t.Errorf("pull request is not replied in thread: %+v", m)
+		}
+	}
+}
+
+func TestPathRuleRetriesWhenFilesAreUnavailable(t *testing.T) {
+	var mu sync.Mutex
+	down := true
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if down {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte(`[{"filename": "api/v1/users.go"}]`))
+	}))
+	defer api.Close()
+	os.Setenv("GLADOS_GITHUB_API_URL", api.URL+"/")
+	os.Setenv("GLADOS_GITHUB_NOTIFIER_ADMIN_TOKEN", "adm")
+	os.Setenv("GLADOS_ADMINS", "root")
+	defer os.Unsetenv("GLADOS_GITHUB_API_URL")
+	defer os.Unsetenv("GLADOS_GITHUB_NOTIFIER_ADMIN_TOKEN")
+	defer os.Unsetenv("GLADOS_ADMINS")
+	h := newHarness(nil)
+	defer h.Shutdown()
+
+	h.ChatAdapter.Say("ops", "root", "GLaDOS github rule add dev --path=/api/**")
+	h.ChatAdapter.Reset()
+	h.Router.Do(gladostest.NewGitHubWebhookRequest("/github/notify_events/dev", "pull_request", testSecret, []byte(pullRequestPayload)))
+	time.Sleep(500 * time.Millisecond)
+	if messages := h.ChatAdapter.Messages(); len(messages) > 0 {
+		t.Fatalf("event is notified without changed files: %+v", messages)
+	}
+	req, _ := http.NewRequest(http.MethodGet, "/github/queue", nil)
+	req.Header.Set("Authorization", "Bearer adm")
+	if body := h.Router.Do(req).Body.String(); !strings.Contains(body, "list pull request files failed") {
+		t.Fatalf("event is not kept in queue for retry: %s", body)
+	}
+
+	mu.Lock()
+	down = false
+	mu.Unlock()
+	if _, ok := h.ChatAdapter.WaitMessages(1, 8*time.Second); !ok {
+		t.Fatalf("event is not retried. %v", h.Logger.Lines())
+	}
+}
@@ -0,0 +1,167 @@
+package githubnotifier
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/astronoka/glados"
+)
+
+const (
+	githubNameNamespace = "githubnotifier.names"
+	chatNameNamespace   = "githubnotifier.chatnames"
+)
+
+// nameDirectory is github name -> chat name mapping of each chat adapter saved in storage.
+// chat name -> github name is also saved to replace previous github name of chat user.
+// keys are qualified by adapter name as "adapter:name", same as users of roles
+type nameDirectory struct {
+	mu      sync.Mutex
+	context glados.Context
+}
+
+// key is storage key of github name or chat name on adapter. empty adapter is default adapter
+func (d *nameDirectory) key(adapter, name string) string {
+	return d.context.Dispatcher().QualifiedUser(adapter, name)
+}
+
+// splitChatUser is return adapter and name of chat user written as "adapter:user" or "user" of adapter
+func splitChatUser(adapter, user string) (string, string) {
+	user = strings.TrimPrefix(user, "@")
+	if i := strings.Index(user, ":"); i >= 0 {
+		return user[:i], user[i+1:]
+	}
+	return adapter, user
+}
+
+// seed is save static name table of default adapter without overwriting names registered from chat
+func (d *nameDirectory) seed(nameTable map[string]string) error {
+	for githubName, chatName := range nameTable {
+		var registered string
+		chatNameExist, err := d.context.Storage().Load(chatNameNamespace, d.key("", chatName), &registered)
+		if err != nil {
+			return err
+		}
+		_, githubNameExist, err := d.chatName("", githubName)
+		if err != nil {
+			return err
+		}
+		if chatNameExist || githubNameExist {
+			continue
+		}
+		if err := d.set("", githubName, chatName, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *nameDirectory) chatName(adapter, githubName string) (string, bool, error) {
+	var chatName string
+	exist, err := d.context.Storage().Load(githubNameNamespace, d.key(adapter, githubName), &chatName)
+	return chatName, exist, err
+}
+
+// githubNameTakenError is error of registering github name of another chat user
+type githubNameTakenError struct {
+	owner string
+}
+
+func (e *githubNameTakenError) Error() string {
+	return "githubnotifier: github name is registered by " + e.owner
+}
+
+// set is map github name to chat name on adapter, and unmap previous github name of the chat user.
+// github name of another chat user is taken from the user only if force is true
+func (d *nameDirectory) set(adapter, githubName, chatName string, force bool) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	owner, ownerExist, err := d.chatName(adapter, githubName)
+	if err != nil {
+		return err
+	}
+	if ownerExist && !strings.EqualFold(owner, chatName) {
+		if !force {
+			return &githubNameTakenError{owner: owner}
+		}
+		if err := d.context.Storage().Delete(chatNameNamespace, d.key(adapter, owner)); err != nil {
+			return err
+		}
+	}
+	var previous string
+	exist, err := d.context.Storage().Load(chatNameNamespace, d.key(adapter, chatName), &previous)
+	if err != nil {
+		return err
+	}
+	if exist && !strings.EqualFold(previous, githubName) {
+		if err := d.context.Storage().Delete(githubNameNamespace, d.key(adapter, previous)); err != nil {
+			return err
+		}
+	}
+	if err := d.context.Storage().Save(githubNameNamespace, d.key(adapter, githubName), chatName); err != nil {
+		return err
+	}
+	return d.context.Storage().Save(chatNameNamespace, d.key(adapter, chatName), githubName)
+}
+
+// ConvertGithubName2ChatName is return chat name of github name on default adapter
+func (p *program) ConvertGithubName2ChatName(githubName string) string {
+	return p.convertGithubName("", githubName)
+}
+
+// convertGithubName is return chat name of github name on adapter, or github name if it is not registered.
+// empty adapter is default adapter
+func (p *program) convertGithubName(adapter, githubName string) string {
+	chatName, exist, err := p.names.chatName(adapter, githubName)
+	if err != nil {
+		p.context.Logger().Warnln("githubnotifier: load name failed. " + err.Error())
+		// storage is unavailable, use static name table
+		if name, exist := p.nameTable[githubName]; exist {
+			return name
+		}
+	}
+	if exist {
+		return chatName
+	}
+	return githubName
+}
+
+func (p *program) registerGithubName(adapter glados.ChatAdapter, message *glados.ChatMessageEvent, args *glados.CommandArgs) {
+	githubName := strings.TrimPrefix(args.String("login"), "@")
+	err := p.names.set(message.Adapter, githubName, message.User, false)
+	if taken, ok := err.(*githubNameTakenError); ok {
+		p.say(adapter, message.Channel, "@"+message.User+" "+githubName+" is already registered by @"+taken.owner+". ask an admin to reassign it")
+		return
+	}
+	if err != nil {
+		p.context.Logger().Warnln("githubnotifier: save name failed. " + err.Error())
+		p.say(adapter, message.Channel, "@"+message.User+" save github name failed")
+		return
+	}
+	p.say(adapter, message.Channel, "@"+message.User+" your github name is "+githubName)
+}
+
+func (p *program) assignGithubName(adapter glados.ChatAdapter, message *glados.ChatMessageEvent, args *glados.CommandArgs) {
+	githubName := strings.TrimPrefix(args.String("login"), "@")
+	chatAdapter, chatName := splitChatUser(message.Adapter, args.String("user"))
+	if err := p.names.set(chatAdapter, githubName, chatName, true); err != nil {
+		p.context.Logger().Warnln("githubnotifier: save name failed. " + err.Error())
+		p.say(adapter, message.Channel, "@"+message.User+" save github name failed")
+		return
+	}
+	p.auditChange(message, "github-name set", chatAdapter+":"+chatName+" "+githubName)
+	p.say(adapter, message.Channel, "@"+message.User+" github name of @"+chatName+" is "+githubName)
+}
+
+func (p *program) sayWhoIs(adapter glados.ChatAdapter, message *glados.ChatMessageEvent, args *glados.CommandArgs) {
+	githubName := strings.TrimPrefix(args.String("login"), "@")
+	chatName, exist, err := p.names.chatName(message.Adapter, githubName)
+	if err != nil {
+		p.context.Logger().Warnln("githubnotifier: load name failed. " + err.Error())
+	}
+	if !exist {
+		p.say(adapter, message.Channel, "@"+message.User+" I don't know "+githubName)
+		return
+	}
+	p.say(adapter, message.Channel, githubName+" is @"+chatName)
+}
@@ -1,9 +1,12 @@
 package githubnotifier
 
 import (
-	"fmt"
+	"context"
+	"net/http"
+	"net/url"
 	"regexp"
 	"strings"
+	"sync"
 
 	"github.com/astronoka/glados"
 	"github.com/google/go-github/github"
@@ -12,7 +15,8 @@ import (
 // GitHubUserNamePattern is regexp for detect github user name
 var GitHubUserNamePattern = regexp.MustCompile(`@([a-zA-Z0-9_-]+)`)
 
-// NewProgram is create test program
+// NewProgram is create test program.
+// nameTable is github name -> chat name mapping seeded into storage
 func NewProgram(nameTable map[string]string) glados.Program {
 	return &program{
 		nameTable: nameTable,
@@ -21,32 +25,158 @@ func NewProgram(nameTable map[string]string) glados.Program {
 
 type program struct {
 	nameTable map[string]string
+	context   glados.Context
+	client    *github.Client
+	rules     *ruleSet
+	templates templateSet
+	names     *nameDirectory
+	queue     *EventQueue
+	// threadMu serializes creation of pull request threads
+	threadMu sync.Mutex
+}
+
+func (p *program) threadLock() sync.Locker {
+	return &p.threadMu
 }
 
 func (p *program) Initialize(c glados.Context) {
+	p.context = c
+	p.client = newGitHubClient(c)
+	p.rules = &ruleSet{context: c}
+	p.names = &nameDirectory{context: c}
+	if err := p.names.seed(p.nameTable); err != nil {
+		c.Logger().Warnln("githubnotifier: seed names failed. " + err.Error())
+	}
+	if filename := c.Env("GLADOS_GITHUB_NOTIFIER_RULES", ""); filename != "" {
+		rules, err := LoadFilterRules(filename)
+		if err != nil {
+			c.Logger().Fatalln("githubnotifier: load rules file failed. " + err.Error())
+		}
+		p.rules.file = rules
+	}
+	var overrides map[string]map[string]MessageTemplate
+	if filename := c.Env("GLADOS_GITHUB_NOTIFIER_TEMPLATES", ""); filename != "" {
+		templates, err := LoadMessageTemplates(filename)
+		if err != nil {
+			c.Logger().Fatalln("githubnotifier: load templates file failed. " + err.Error())
+		}
+		overrides = templates
+	}
+	templates, err := compileTemplates(p.templateFuncs(), DefaultMessageTemplates, overrides)
+	if err != nil {
+		c.Logger().Fatalln(err.Error())
+	}
+	p.templates = templates
+
 	secret := c.Env("GLADOS_GITHUB_NOTIFIER_SECRET", random())
 	// destination -> channel_name
-	c.Router().POST("/github/notify_events/:destination", NotifyEvent(c, p, secret))
-	c.ChatAdapter().Respond(`(?i)ping$`, sayPong)
+	p.queue = NewEventQueue(c, p)
+	c.Router().POST("/github/notify_events/:destination", EnqueueEvent(c, p.queue, secret))
+	if token := c.Env("GLADOS_GITHUB_NOTIFIER_ADMIN_TOKEN", ""); token != "" {
+		c.Router().GET("/github/deliveries", glados.RequireToken(token, ListDeliveries(c)))
+		c.Router().POST("/github/deliveries/:id/replay", glados.RequireToken(token, ReplayDelivery(c, p)))
+		c.Router().GET("/github/queue", glados.RequireToken(token, ListQueue(p.queue)))
+		c.Router().POST("/github/queue/dead/:id/retry", glados.RequireToken(token, RetryDeadEvent(p.queue)))
+	}
+	c.ChatAdapter().Respond(`(?i)ping$`, p.sayPong,
+		glados.WithHelp("ping", "reply pong"))
+	c.Dispatcher().Command("github-name is <login>", p.registerGithubName,
+		glados.WithDescription("register your github name"))
+	c.Dispatcher().Command("github-name set <user> <login>", p.assignGithubName,
+		glados.WithDescription("register github name of user. it is taken from another user"),
+		glados.RequireRole(glados.RoleAdmin))
+	c.Dispatcher().Command("who is <login>", p.sayWhoIs,
+		glados.WithDescription("show chat user of github name"))
+	c.Dispatcher().Command("github rule list <destination>", p.listRules,
+		glados.WithDescription("show notification rules of destination"))
+	c.Dispatcher().Command("github rule add <destination> "+ruleFlags, p.addRule,
+		glados.WithDescription("add notification rule. flags take comma separated globs"),
+		glados.RequireRole(glados.RoleAdmin))
+	c.Dispatcher().Command("github rule remove <destination> <index:int>", p.removeRule,
+		glados.WithDescription("remove notification rule"),
+		glados.RequireRole(glados.RoleAdmin))
+	c.Dispatcher().Command("github rule reset <destination>", p.resetRules,
+		glados.WithDescription("discard edited rules and use rules file"),
+		glados.RequireRole(glados.RoleAdmin))
+}
+
+// Start is start notifying queued webhook events
+func (p *program) Start(c glados.Context) error {
+	p.queue.Start()
+	return nil
 }
 
-func sayPong(adapter glados.ChatAdapter, message *glados.ChatMessageEvent) {
-	adapter.PostTextMessage(message.Channel, "@"+message.User+" pong")
+// Stop is stop notifying queued webhook events. unfinished events are notified after restart
+func (p *program) Stop(ctx context.Context) error {
+	return p.queue.Stop(ctx)
 }
 
-func (p *program) ConvertGithubName2ChatName(githubName string) string {
-	if name, exist := p.nameTable[githubName]; exist {
-		return name
+// newGitHubClient is create github api client used to fetch pull request files
+func newGitHubClient(c glados.Context) *github.Client {
+	httpClient := http.DefaultClient
+	if token := c.Env("GLADOS_GITHUB_NOTIFIER_TOKEN", ""); token != "" {
+		httpClient = &http.Client{Transport: &tokenTransport{token: token}}
+	}
+	client := github.NewClient(httpClient)
+	if apiURL := c.Env("GLADOS_GITHUB_API_URL", ""); apiURL != "" {
+		baseURL, err := url.Parse(apiURL)
+		if err != nil {
+			c.Logger().Fatalln("githubnotifier: invalid GLADOS_GITHUB_API_URL. " + err.Error())
+		}
+		client.BaseURL = baseURL
 	}
-	return githubName
+	return client
+}
+
+type tokenTransport struct {
+	token string
+}
+
+func (t *tokenTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	r := new(http.Request)
+	*r = *req
+	r.Header = make(http.Header, len(req.Header))
+	for k, v := range req.Header {
+		r.Header[k] = v
+	}
+	r.Header.Set("Authorization", "token "+t.token)
+	return http.DefaultTransport.RoundTrip(r)
+}
+
+func (p *program) sayPong(adapter glados.ChatAdapter, message *glados.ChatMessageEvent) {
+	p.say(adapter, message.Channel, "@"+message.User+" pong")
 }
 
+// say is post reply of chat command. failure is logged because there is no one to report to
+func (p *program) say(adapter glados.ChatAdapter, channel, text string) {
+	if _, err := adapter.PostTextMessage(channel, text); err != nil {
+		p.context.Logger().Warnln("githubnotifier: post message failed. " + err.Error())
+	}
+}
+
+// auditChange is record privileged change made by chat command
+func (p *program) auditChange(message *glados.ChatMessageEvent, handler, arguments string) {
+	p.context.Audit().Record(glados.AuditEntry{
+		Kind:      glados.AuditPrivileged,
+		Adapter:   message.Adapter,
+		User:      message.User,
+		Channel:   message.Channel,
+		Handler:   handler,
+		Arguments: arguments,
+	})
+}
+
+// ConvertGithubName2ChatNameInText is replace github names in text with chat names on default adapter
 func (p *program) ConvertGithubName2ChatNameInText(text string) string {
+	return p.convertGithubNamesInText("", text)
+}
+
+func (p *program) convertGithubNamesInText(adapter, text string) string {
 	var oldNew []string
 	matched := GitHubUserNamePattern.FindAllStringSubmatch(text, -1)
 	for _, m := range matched {
 		githubName := m[1]
-		chatName := p.ConvertGithubName2ChatName(githubName)
+		chatName := p.convertGithubName(adapter, githubName)
 		if chatName == "" {
 			continue
 		}
@@ -60,77 +190,61 @@ func (p *program) ConvertGithubName2ChatNameInText(text string) string {
 }
 
 func (p *program) ConvertEventToChatMessage(event interface{}) *glados.ChatMessage {
-	switch event := event.(type) {
-	case *github.PullRequestEvent:
-		return p.buildPullRequestEventMessage(event)
-	case *github.IssueCommentEvent:
-		return p.buildIssueCommentEventMessage(event)
-	case *github.PullRequestReviewCommentEvent:
-		return p.buildPullRequestReviewCommentEventMessage(event)
-	}
-	return nil
+	return p.ConvertEventToDestinationMessage("", event)
 }
 
-func (p *program) buildPullRequestEventMessage(event *github.PullRequestEvent) *glados.ChatMessage {
-	author := glados.MessageAuthor{
-		Name:    *event.PullRequest.User.Login,
-		Subname: p.ConvertGithubName2ChatName(*event.PullRequest.User.Login),
-		Link:    *event.PullRequest.User.HTMLURL,
-		IconURL: *event.PullRequest.User.AvatarURL,
+// ConvertEventToDestinationMessage is implement GitHubDestinationConverter
+func (p *program) ConvertEventToDestinationMessage(destination string, event interface{}) *glados.ChatMessage {
+	author, ok := notificationAuthor(event)
+	if !ok {
+		return nil
 	}
-	text := p.ConvertGithubName2ChatNameInText(*event.PullRequest.Body) +
-		"\n" +
-		fmt.Sprintf(`<%s|github>`, event.PullRequest.HTMLURL)
-	return &glados.ChatMessage{
-		Author: author,
-		Title:  fmt.Sprintf("%s: pull reques %s", *event.Repo.FullName, *event.Action),
-		Text:   text,
-		Color:  "#000000",
+	subject := p.buildEventSubject(event)
+	var keys []string
+	for i := len(subject.actions) - 1; i >= 0; i-- {
+		keys = append(keys, subject.event+"."+subject.actions[i])
 	}
+	keys = append(keys, subject.event)
+	return p.renderMessage(destination, keys, event, author)
 }
 
-func (p *program) buildIssueCommentEventMessage(event *github.IssueCommentEvent) *glados.ChatMessage {
-	if *event.Action != "created" {
+// ConvertEventToThreadRootMessage is implement GitHubThreadRootConverter.
+// root is built from pull request carried by every pull request event, so that comment is never used as root
+func (p *program) ConvertEventToThreadRootMessage(destination string, event interface{}) *glados.ChatMessage {
+	e, ok := threadRootEvent(event)
+	if !ok {
 		return nil
 	}
-	text := "issue owner: @" + p.ConvertGithubName2ChatName(*event.Issue.User.Login) +
-		"\n" +
-		p.ConvertGithubName2ChatNameInText(*event.Comment.Body) +
-		"\n" +
-		fmt.Sprintf(`<%s|github>`, event.Comment.HTMLURL)
-	author := glados.MessageAuthor{
-		Name:    *event.Sender.Login,
-		Subname: p.ConvertGithubName2ChatName(*event.Sender.Login),
-		Link:    *event.Sender.HTMLURL,
-		IconURL: *event.Sender.AvatarURL,
-	}
-	return &glados.ChatMessage{
-		Author: author,
-		Title:  fmt.Sprintf("%s: issue comment created", *event.Repo.FullName),
-		Text:   text,
-		Color:  "#000000",
-	}
+	return p.renderMessage(destination, []string{pullRequestThreadTemplate}, e, e.GetPullRequest().GetUser())
 }
 
-func (p *program) buildPullRequestReviewCommentEventMessage(event *github.PullRequestReviewCommentEvent) *glados.ChatMessage {
-	if *event.Action != "created" {
-		return nil
-	}
-	author := glados.MessageAuthor{
-		Name:    *event.Sender.Login,
-		Subname: p.ConvertGithubName2ChatName(*event.Sender.Login),
-		Link:    *event.Sender.HTMLURL,
-		IconURL: *event.Sender.AvatarURL,
-	}
-	text := "pull request owner: @" + p.ConvertGithubName2ChatName(*event.PullRequest.User.Login) +
-		"\n" +
-		p.ConvertGithubName2ChatNameInText(*event.Comment.Body) +
-		"\n" +
-		fmt.Sprintf(`<%s|github>`, event.Comment.HTMLURL)
-	return &glados.ChatMessage{
-		Author: author,
-		Title:  fmt.Sprintf("%s: review comment created", *event.Repo.FullName),
-		Text:   text,
-		Color:  "#000000",
+// threadRootEvent is return pull request event rendered as thread root of pull request event.
+// pull request of issue comment is built from the issue, so it lacks merged state
+func threadRootEvent(event interface{}) (*github.PullRequestEvent, bool) {
+	switch e := event.(type) {
+	case *github.PullRequestEvent:
+		return e, true
+	case *github.PullRequestReviewEvent:
+		return &github.PullRequestEvent{PullRequest: e.PullRequest, Repo: e.Repo, Sender: e.Sender}, true
+	case *github.PullRequestReviewCommentEvent:
+		return &github.PullRequestEvent{PullRequest: e.PullRequest, Repo: e.Repo, Sender: e.Sender}, true
+	case *github.IssueCommentEvent:
+		if e.GetIssue().GetPullRequestLinks() == nil {
+			return nil, false
+		}
+		issue := e.GetIssue()
+		return &github.PullRequestEvent{
+			PullRequest: &github.PullRequest{
+				Number:  issue.Number,
+				State:   issue.State,
+				Title:   issue.Title,
+				Body:    issue.Body,
+				HTMLURL: issue.HTMLURL,
+				User:    issue.User,
+			},
+			Repo:   e.Repo,
+			Sender: e.Sender,
+		}, true
 	}
+	return nil, false
 }
@@ -0,0 +1,123 @@
+package githubnotifier_test
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/astronoka/glados/gladostest"
+)
+
+func lastText(t *testing.T, texts []string) string {
+	if len(texts) <= 0 {
+		t.Fatal("no message is posted")
+	}
+	return texts[len(texts)-1]
+}
+
+func TestRuleCommandsRequireAdmin(t *testing.T) {
+	os.Setenv("GLADOS_ADMINS", "root")
+	defer os.Unsetenv("GLADOS_ADMINS")
+	h := newHarness(nil)
+	defer h.Shutdown()
+
+	texts := func() []string {
+		var texts []string
+		for _, m := range h.ChatAdapter.Messages() {
+			texts = append(texts, m.Message.Text)
+		}
+		return texts
+	}
+	for _, command := range []string{
+		"GLaDOS github rule add dev --event=push",
+		"GLaDOS github rule remove dev 0",
+		"GLaDOS github rule reset dev",
+	} {
+		h.ChatAdapter.Say("ops", "bob", command)
+		if got := lastText(t, texts()); !strings.HasPrefix(got, "Sorry @bob") {
+			t.Errorf("%q by non-admin is not denied: %q", command, got)
+		}
+	}
+	h.ChatAdapter.Say("ops", "bob", "GLaDOS github rule list dev")
+	if got := lastText(t, texts()); !strings.Contains(got, "has no rule") {
+		t.Errorf("rule is changed by non-admin: %q", got)
+	}
+
+	h.ChatAdapter.Say("ops", "root", "GLaDOS github rule add dev --event=push")
+	h.ChatAdapter.Say("ops", "bob", "GLaDOS github rule list dev")
+	if got := lastText(t, texts()); !strings.Contains(got, "--event=push") {
+		t.Errorf("rule is not added by admin: %q", got)
+	}
+}
+
+func TestGithubNameIsNotStolen(t *testing.T) {
+	os.Setenv("GLADOS_ADMINS", "root")
+	defer os.Unsetenv("GLADOS_ADMINS")
+	h := newHarness(nil)
+	defer h.Shutdown()
+
+	say := func(user, text string) string {
+		h.ChatAdapter.Say("general", user, "GLaDOS "+text)
+		messages := h.ChatAdapter.Messages()
+		return messages[len(messages)-1].Message.Text
+	}
+	say("alice", "github-name is octocat")
+	if got := say("bob", "github-name is octocat"); !strings.Contains(got, "already registered by @alice") {
+		t.Errorf("github name of alice is taken by bob: %q", got)
+	}
+	if got := say("bob", "who is octocat"); got != "octocat is @alice" {
+		t.Errorf("who is octocat = %q", got)
+	}
+
+	say("root", "github-name set bob octocat")
+	if got := say("bob", "who is octocat"); got != "octocat is @bob" {
+		t.Errorf("who is octocat = %q after reassign", got)
+	}
+	// alice registering another name must not unmap name of bob
+	say("alice", "github-name is alicegh")
+	if got := say("bob", "who is octocat"); got != "octocat is @bob" {
+		t.Errorf("who is octocat = %q after alice changed name", got)
+	}
+	if got := say("bob", "who is alicegh"); got != "alicegh is @alice" {
+		t.Errorf("who is alicegh = %q", got)
+	}
+	if got := say("bob", "github-name set bob octocat"); !strings.HasPrefix(got, "Sorry @bob") {
+		t.Errorf("github-name set by non-admin is not denied: %q", got)
+	}
+}
+
+func TestGithubNamesAreQualifiedByAdapter(t *testing.T) {
+	os.Setenv("GLADOS_ADMINS", "root")
+	defer os.Unsetenv("GLADOS_ADMINS")
+	h := newHarness(nil)
+	ops := h.AddChatAdapter("ops")
+	defer h.Shutdown()
+
+	say := func(adapter *gladostest.ChatAdapter, user, text string) string {
+		adapter.Say("general", user, "GLaDOS "+text)
+		messages := adapter.Messages()
+		return messages[len(messages)-1].Message.Text
+	}
+	say(h.ChatAdapter, "alice", "github-name is octocat")
+	if got := say(ops, "alice", "who is octocat"); got != "@alice I don't know octocat" {
+		t.Errorf("who is octocat on ops = %q", got)
+	}
+	// alice of ops is another user, and may register same github name
+	say(ops, "alice", "github-name is octocat")
+	say(h.ChatAdapter, "root", "github-name set ops:bob hubot")
+	tests := []struct {
+		adapter *gladostest.ChatAdapter
+		login   string
+		want    string
+	}{
+		{h.ChatAdapter, "octocat", "octocat is @alice"},
+		{ops, "octocat", "octocat is @alice"},
+		{ops, "hubot", "hubot is @bob"},
+		{h.ChatAdapter, "hubot", "@bob I don't know hubot"},
+	}
+	for _, test := range tests {
+		if got := say(test.adapter, "bob", "who is "+test.login); got != test.want {
+			t.Errorf("%s: who is %s = %q, want %q", h.Context.ChatAdapterName(test.adapter), test.login, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,347 @@
+package githubnotifier
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/astronoka/glados"
+	"github.com/google/go-github/github"
+)
+
+const (
+	queueNamespace = "githubnotifier.queue"
+	// queuePendingKey is key of queued event ids in arrival order
+	queuePendingKey = "pending"
+	// queueDeadKey is key of event ids given up after maxQueueAttempts
+	queueDeadKey = "dead"
+
+	maxQueueAttempts  = 8
+	queueBaseBackoff  = 5 * time.Second
+	queueMaxBackoff   = 10 * time.Minute
+	queuePollInterval = time.Second
+)
+
+// queuedEvent is webhook delivery waiting to be notified
+type queuedEvent struct {
+	ID          string          `json:"id"`
+	Delivery    webhookDelivery `json:"delivery"`
+	Attempts    int             `json:"attempts"`
+	NextAttempt time.Time       `json:"next_attempt"`
+	LastError   string          `json:"last_error,omitempty"`
+}
+
+// EventQueue is storage backed queue notifying webhook events in background.
+// failed event is retried with exponential backoff, and moved to dead letter list after maxQueueAttempts
+type EventQueue struct {
+	mu        sync.Mutex
+	context   glados.Context
+	converter GitHubEventConverter
+	wake      chan struct{}
+	stop      chan struct{}
+	done      chan struct{}
+	started   bool
+	stopped   bool
+	stopOnce  sync.Once
+}
+
+// NewEventQueue is create event queue. events are notified after Start
+func NewEventQueue(c glados.Context, converter GitHubEventConverter) *EventQueue {
+	return &EventQueue{
+		context:   c,
+		converter: converter,
+		wake:      make(chan struct{}, 1),
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+}
+
+func queueKey(id string) string {
+	return "event:" + id
+}
+
+func queueBackoff(attempts int) time.Duration {
+	backoff := queueBaseBackoff
+	for i := 1; i < attempts && backoff < queueMaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > queueMaxBackoff {
+		return queueMaxBackoff
+	}
+	return backoff
+}
+
+func (q *EventQueue) enqueue(delivery *webhookDelivery) error {
+	id := delivery.ID
+	if id == "" {
+		id = random()
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	err := q.context.Storage().Save(queueNamespace, queueKey(id), queuedEvent{
+		ID:          id,
+		Delivery:    *delivery,
+		NextAttempt: delivery.ReceivedAt,
+	})
+	if err != nil {
+		return err
+	}
+	if err := q.appendID(queuePendingKey, id); err != nil {
+		return err
+	}
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Start is start notifying queued events in background. it does nothing after Start or Stop
+func (q *EventQueue) Start() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.started || q.stopped {
+		return
+	}
+	q.started = true
+	go q.run()
+}
+
+// Stop is stop background worker after current event. it returns immediately if worker is not started
+func (q *EventQueue) Stop(ctx context.Context) error {
+	q.mu.Lock()
+	started := q.started
+	q.stopped = true
+	q.mu.Unlock()
+	q.stopOnce.Do(func() {
+		close(q.stop)
+	})
+	if !started {
+		return nil
+	}
+	select {
+	case <-q.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (q *EventQueue) run() {
+	defer close(q.done)
+	for {
+		q.processDue()
+		select {
+		case <-q.stop:
+			return
+		case <-q.wake:
+		case <-time.After(queuePollInterval):
+		}
+	}
+}
+
+func (q *EventQueue) processDue() {
+	ids, err := q.loadIDs(queuePendingKey)
+	if err != nil {
+		q.context.Logger().Warnln("githubnotifier: load queue failed. " + err.Error())
+		return
+	}
+	for _, id := range ids {
+		select {
+		case <-q.stop:
+			return
+		default:
+		}
+		job := queuedEvent{}
+		exist, err := q.context.Storage().Load(queueNamespace, queueKey(id), &job)
+		if err != nil {
+			q.context.Logger().Warnln("githubnotifier: load queued event failed. " + err.Error())
+			continue
+		}
+		if !exist {
+			job.ID = id
+			q.finish(&job, nil)
+			continue
+		}
+		if job.NextAttempt.After(time.Now()) {
+			continue
+		}
+		q.finish(&job, q.notify(&job))
+	}
+}
+
+func (q *EventQueue) notify(job *queuedEvent) error {
+	event, err := github.ParseWebHook(job.Delivery.EventType, []byte(job.Delivery.Payload))
+	if err != nil {
+		return err
+	}
+	return notifyEventToChatAdapter(q.context, job.Delivery.Destination, event, q.converter)
+}
+
+// finish is remove notified event from queue, or schedule retry of failed event
+func (q *EventQueue) finish(job *queuedEvent, notifyErr error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var err error
+	switch {
+	case notifyErr == nil:
+		if err = q.removeID(queuePendingKey, job.ID); err == nil {
+			err = q.context.Storage().Delete(queueNamespace, queueKey(job.ID))
+		}
+	case job.Attempts+1 < maxQueueAttempts:
+		job.Attempts++
+		job.LastError = notifyErr.Error()
+		job.NextAttempt = time.Now().Add(queueBackoff(job.Attempts))
+		q.context.Logger().Warnln("githubnotifier: notify queued event " + job.ID + " failed. " + notifyErr.Error())
+		err = q.context.Storage().Save(queueNamespace, queueKey(job.ID), job)
+	default:
+		job.Attempts++
+		job.LastError = notifyErr.Error()
+		q.context.Logger().Errorln("githubnotifier: give up queued event " + job.ID + ". " + notifyErr.Error())
+		if err = q.context.Storage().Save(queueNamespace, queueKey(job.ID), job); err == nil {
+			if err = q.removeID(queuePendingKey, job.ID); err == nil {
+				err = q.appendID(queueDeadKey, job.ID)
+			}
+		}
+	}
+	if err != nil {
+		q.context.Logger().Warnln("githubnotifier: update queue failed. " + err.Error())
+	}
+}
+
+// retryDead is move dead letter back to queue
+func (q *EventQueue) retryDead(id string) (bool, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	dead, err := q.loadIDs(queueDeadKey)
+	if err != nil {
+		return false, err
+	}
+	found := false
+	for _, deadID := range dead {
+		found = found || deadID == id
+	}
+	job := queuedEvent{}
+	exist, err := q.context.Storage().Load(queueNamespace, queueKey(id), &job)
+	if err != nil || !found || !exist {
+		return false, err
+	}
+	job.Attempts = 0
+	job.NextAttempt = time.Now()
+	if err := q.context.Storage().Save(queueNamespace, queueKey(id), job); err != nil {
+		return false, err
+	}
+	if err := q.removeID(queueDeadKey, id); err != nil {
+		return false, err
+	}
+	if err := q.appendID(queuePendingKey, id); err != nil {
+		return false, err
+	}
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+	return true, nil
+}
+
+func (q *EventQueue) loadIDs(key string) ([]string, error) {
+	var ids []string
+	_, err := q.context.Storage().Load(queueNamespace, key, &ids)
+	return ids, err
+}
+
+func (q *EventQueue) appendID(key, id string) error {
+	ids, err := q.loadIDs(key)
+	if err != nil {
+		return err
+	}
+	return q.context.Storage().Save(queueNamespace, key, append(ids, id))
+}
+
+func (q *EventQueue) removeID(key, id string) error {
+	ids, err := q.loadIDs(key)
+	if err != nil {
+		return err
+	}
+	rest := []string{}
+	for _, i := range ids {
+		if i != id {
+			rest = append(rest, i)
+		}
+	}
+	return q.context.Storage().Save(queueNamespace, key, rest)
+}
+
+func (q *EventQueue) loadEvents(key string) ([]queuedEvent, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	ids, err := q.loadIDs(key)
+	if err != nil {
+		return nil, err
+	}
+	jobs := []queuedEvent{}
+	for _, id := range ids {
+		job := queuedEvent{}
+		exist, err := q.context.Storage().Load(queueNamespace, queueKey(id), &job)
+		if err != nil {
+			return nil, err
+		}
+		if exist {
+			job.Delivery.Payload = ""
+			jobs = append(jobs, job)
+		}
+	}
+	return jobs, nil
+}
+
+// ListQueue is create request handler listing pending and dead events
+func ListQueue(queue *EventQueue) glados.RequestHandler {
+	return func(rc glados.RequestContext) {
+		pending, err := queue.loadEvents(queuePendingKey)
+		var dead []queuedEvent
+		if err == nil {
+			dead, err = queue.loadEvents(queueDeadKey)
+		}
+		if err != nil {
+			queue.context.Logger().Warnln("githubnotifier: load queue failed. " + err.Error())
+			rc.JSON(http.StatusInternalServerError, glados.H{
+				"message": "githubnotifier: load queue failed",
+			})
+			return
+		}
+		rc.JSON(http.StatusOK, glados.H{
+			"pending": pending,
+			"dead":    dead,
+		})
+	}
+}
+
+// RetryDeadEvent is create request handler moving dead event back to queue
+func RetryDeadEvent(queue *EventQueue) glados.RequestHandler {
+	return func(rc glados.RequestContext) {
+		found, err := queue.retryDead(rc.Param("id"))
+		if err != nil {
+			queue.context.Logger().Warnln("githubnotifier: retry dead event failed. " + err.Error())
+			rc.JSON(http.StatusInternalServerError, glados.H{
+				"message": "githubnotifier: retry dead event failed",
+			})
+			return
+		}
+		if !found {
+			rc.JSON(http.StatusNotFound, glados.H{
+				"message": "githubnotifier: dead event not found",
+			})
+			return
+		}
+		queue.context.Audit().Record(glados.AuditEntry{
+			Kind:      glados.AuditPrivileged,
+			Handler:   "github queue retry",
+			Arguments: rc.Param("id"),
+			Detail:    "from " + rc.Request().RemoteAddr,
+		})
+		rc.JSON(http.StatusAccepted, glados.H{
+			"message": "accepted",
+		})
+	}
+}
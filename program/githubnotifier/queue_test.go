@@ -0,0 +1,83 @@
+package githubnotifier
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/astronoka/glados/gladostest"
+)
+
+const queueTestPayload = `{
+	"action": "opened",
+	"pull_request": {"number": 3, "state": "open", "title": "Add queue", "user": {"login": "octocat"}},
+	"repository": {"full_name": "astronoka/glados"},
+	"sender": {"login": "octocat"}
+}`
+
+func TestEventQueueShutdown(t *testing.T) {
+	os.Setenv("GLADOS_GITHUB_NOTIFIER_SECRET", "s3cret")
+	h := gladostest.New()
+	p := NewProgram(nil).(*program)
+	h.Install(p)
+	h.Boot()
+
+	h.Router.Do(gladostest.NewGitHubWebhookRequest("/github/notify_events/dev", "pull_request", "s3cret", []byte(queueTestPayload)))
+	if _, ok := h.ChatAdapter.WaitMessages(1, 3*time.Second); !ok {
+		t.Fatalf("queued event is not notified by worker. %v", h.Logger.Lines())
+	}
+	shutdown := make(chan error, 1)
+	go func() {
+		shutdown <- h.Shutdown()
+	}()
+	select {
+	case err := <-shutdown:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("Shutdown does not return")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := p.queue.Stop(ctx); err != nil {
+		t.Errorf("second Stop returned %v", err)
+	}
+
+	// worker is not restarted after Stop, so event is kept in queue
+	p.queue.Start()
+	if err := p.queue.enqueue(&webhookDelivery{
+		ID:          "after-stop",
+		EventType:   "pull_request",
+		Destination: "dev",
+		Payload:     queueTestPayload,
+		ReceivedAt:  time.Now(),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if messages, ok := h.ChatAdapter.WaitMessages(2, 2*queuePollInterval); ok {
+		t.Fatalf("event is notified after Stop: %+v", messages[1])
+	}
+	pending, err := p.queue.loadIDs(queuePendingKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 1 || pending[0] != "after-stop" {
+		t.Errorf("pending = %v, want [after-stop]", pending)
+	}
+}
+
+func TestEventQueueStopWithoutStart(t *testing.T) {
+	h := gladostest.New()
+	p := NewProgram(nil).(*program)
+	h.Install(p)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	for i := 0; i < 2; i++ {
+		if err := p.queue.Stop(ctx); err != nil {
+			t.Errorf("Stop #%d returned %v", i+1, err)
+		}
+	}
+}
@@ -0,0 +1,250 @@
+package githubnotifier
+
+import (
+	"fmt"
+	"reflect"
+	"text/template"
+	"text/template/parse"
+)
+
+// checkTemplate is check fields, methods and function calls of template against data type.
+// bodies of range, if and with are checked too, though they are not executed with empty event
+func checkTemplate(t *template.Template, funcs template.FuncMap, data reflect.Type) error {
+	if t.Tree == nil {
+		return nil
+	}
+	c := &templateChecker{funcs: funcs}
+	if err := c.walk(t.Tree.Root, data, map[string]reflect.Type{"$": data}); err != nil {
+		return fmt.Errorf("template: %s: %s", t.Name(), err.Error())
+	}
+	return nil
+}
+
+// templateChecker is walk template parse tree with type of dot.
+// nil type is unknown type such as interface{}, and is not checked
+type templateChecker struct {
+	funcs template.FuncMap
+}
+
+func copyTypes(vars map[string]reflect.Type) map[string]reflect.Type {
+	copied := make(map[string]reflect.Type, len(vars))
+	for name, typ := range vars {
+		copied[name] = typ
+	}
+	return copied
+}
+
+func (c *templateChecker) walk(node parse.Node, dot reflect.Type, vars map[string]reflect.Type) error {
+	switch node := node.(type) {
+	case *parse.ListNode:
+		if node == nil {
+			return nil
+		}
+		for _, n := range node.Nodes {
+			if err := c.walk(n, dot, vars); err != nil {
+				return err
+			}
+		}
+	case *parse.ActionNode:
+		_, err := c.pipe(node.Pipe, dot, vars, true)
+		return err
+	case *parse.IfNode:
+		return c.branch(&node.BranchNode, parse.NodeIf, dot, vars)
+	case *parse.WithNode:
+		return c.branch(&node.BranchNode, parse.NodeWith, dot, vars)
+	case *parse.RangeNode:
+		return c.branch(&node.BranchNode, parse.NodeRange, dot, vars)
+	case *parse.TemplateNode:
+		_, err := c.pipe(node.Pipe, dot, vars, false)
+		return err
+	}
+	return nil
+}
+
+func (c *templateChecker) branch(node *parse.BranchNode, kind parse.NodeType, dot reflect.Type, vars map[string]reflect.Type) error {
+	scoped := copyTypes(vars)
+	typ, err := c.pipe(node.Pipe, dot, scoped, kind != parse.NodeRange)
+	if err != nil {
+		return err
+	}
+	body := dot
+	switch kind {
+	case parse.NodeWith:
+		body = typ
+	case parse.NodeRange:
+		key, elem, err := rangeTypes(typ)
+		if err != nil {
+			return err
+		}
+		body = elem
+		switch len(node.Pipe.Decl) {
+		case 1:
+			scoped[node.Pipe.Decl[0].Ident[0]] = elem
+		case 2:
+			scoped[node.Pipe.Decl[0].Ident[0]] = key
+			scoped[node.Pipe.Decl[1].Ident[0]] = elem
+		}
+	}
+	if err := c.walk(node.List, body, scoped); err != nil {
+		return err
+	}
+	return c.walk(node.ElseList, dot, scoped)
+}
+
+// rangeTypes is return key and element type of ranged value
+func rangeTypes(typ reflect.Type) (reflect.Type, reflect.Type, error) {
+	if typ == nil {
+		return nil, nil, nil
+	}
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	switch typ.Kind() {
+	case reflect.Slice, reflect.Array:
+		return reflect.TypeOf(0), typ.Elem(), nil
+	case reflect.Map:
+		return typ.Key(), typ.Elem(), nil
+	case reflect.Chan:
+		return typ.Elem(), typ.Elem(), nil
+	case reflect.Interface:
+		return nil, nil, nil
+	}
+	return nil, nil, fmt.Errorf("range can't iterate over %s", typ)
+}
+
+func (c *templateChecker) pipe(pipe *parse.PipeNode, dot reflect.Type, vars map[string]reflect.Type, declare bool) (reflect.Type, error) {
+	if pipe == nil {
+		return nil, nil
+	}
+	var typ reflect.Type
+	for i, command := range pipe.Cmds {
+		var err error
+		if typ, err = c.command(command, dot, vars, i > 0); err != nil {
+			return nil, err
+		}
+	}
+	if declare {
+		for _, variable := range pipe.Decl {
+			vars[variable.Ident[0]] = typ
+		}
+	}
+	return typ, nil
+}
+
+// command is return result type of command. piped command receives result of previous command as last argument
+func (c *templateChecker) command(command *parse.CommandNode, dot reflect.Type, vars map[string]reflect.Type, piped bool) (reflect.Type, error) {
+	if identifier, ok := command.Args[0].(*parse.IdentifierNode); ok {
+		return c.function(identifier.Ident, command.Args[1:], dot, vars, piped)
+	}
+	for _, arg := range command.Args[1:] {
+		if _, err := c.arg(arg, dot, vars); err != nil {
+			return nil, err
+		}
+	}
+	return c.arg(command.Args[0], dot, vars)
+}
+
+func (c *templateChecker) arg(node parse.Node, dot reflect.Type, vars map[string]reflect.Type) (reflect.Type, error) {
+	switch node := node.(type) {
+	case *parse.DotNode:
+		return dot, nil
+	case *parse.FieldNode:
+		return fieldsType(dot, node.Ident)
+	case *parse.VariableNode:
+		typ, exist := vars[node.Ident[0]]
+		if !exist {
+			return nil, fmt.Errorf("undefined variable %s", node.Ident[0])
+		}
+		return fieldsType(typ, node.Ident[1:])
+	case *parse.ChainNode:
+		typ, err := c.arg(node.Node, dot, vars)
+		if err != nil {
+			return nil, err
+		}
+		return fieldsType(typ, node.Field)
+	case *parse.PipeNode:
+		return c.pipe(node, dot, vars, false)
+	case *parse.IdentifierNode:
+		return c.function(node.Ident, nil, dot, vars, false)
+	case *parse.StringNode:
+		return reflect.TypeOf(""), nil
+	case *parse.BoolNode:
+		return reflect.TypeOf(false), nil
+	}
+	return nil, nil
+}
+
+func (c *templateChecker) function(name string, args []parse.Node, dot reflect.Type, vars map[string]reflect.Type, piped bool) (reflect.Type, error) {
+	for _, arg := range args {
+		if _, err := c.arg(arg, dot, vars); err != nil {
+			return nil, err
+		}
+	}
+	if fn, exist := c.funcs[name]; exist {
+		typ := reflect.TypeOf(fn)
+		n := len(args)
+		if piped {
+			n++
+		}
+		if typ.IsVariadic() && n < typ.NumIn()-1 || !typ.IsVariadic() && n != typ.NumIn() {
+			return nil, fmt.Errorf("wrong number of args for %s: want %d got %d", name, typ.NumIn(), n)
+		}
+		if typ.NumOut() <= 0 {
+			return nil, nil
+		}
+		return typ.Out(0), nil
+	}
+	switch name {
+	case "len":
+		return reflect.TypeOf(0), nil
+	case "not", "eq", "ne", "lt", "le", "gt", "ge":
+		return reflect.TypeOf(false), nil
+	case "print", "printf", "println", "html", "js", "urlquery":
+		return reflect.TypeOf(""), nil
+	}
+	return nil, nil
+}
+
+// fieldsType is return type of field chain such as .GetRepo.GetFullName
+func fieldsType(typ reflect.Type, names []string) (reflect.Type, error) {
+	for _, name := range names {
+		if typ == nil {
+			return nil, nil
+		}
+		next, err := fieldType(typ, name)
+		if err != nil {
+			return nil, err
+		}
+		typ = next
+	}
+	return typ, nil
+}
+
+// fieldType is return result type of method or type of field, same as lookup of text/template
+func fieldType(typ reflect.Type, name string) (reflect.Type, error) {
+	method, exist := typ.MethodByName(name)
+	if !exist && typ.Kind() != reflect.Ptr && typ.Kind() != reflect.Interface {
+		method, exist = reflect.PtrTo(typ).MethodByName(name)
+	}
+	if exist {
+		if method.Type.NumOut() <= 0 {
+			return nil, fmt.Errorf("method %s of type %s has no result", name, typ)
+		}
+		return method.Type.Out(0), nil
+	}
+	base := typ
+	for base.Kind() == reflect.Ptr {
+		base = base.Elem()
+	}
+	switch base.Kind() {
+	case reflect.Struct:
+		if field, exist := base.FieldByName(name); exist && field.PkgPath == "" {
+			return field.Type, nil
+		}
+	case reflect.Map:
+		return base.Elem(), nil
+	case reflect.Interface:
+		return nil, nil
+	}
+	return nil, fmt.Errorf("can't evaluate field %s in type %s", name, typ)
+}
@@ -0,0 +1,340 @@
+package githubnotifier
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/astronoka/glados"
+	"github.com/google/go-github/github"
+)
+
+// MessageTemplate is text/template of chat message fields.
+// the template data is github event such as *github.PullRequestEvent
+type MessageTemplate struct {
+	Title     string `json:"title"`
+	TitleLink string `json:"title_link"`
+	Text      string `json:"text"`
+	Color     string `json:"color"`
+}
+
+// allDestinations is destination key of templates overriding every destination
+const allDestinations = "*"
+
+// pullRequestThreadTemplate is template key of pull request thread root message
+const pullRequestThreadTemplate = "pull_request_thread"
+
+// templateEvents is event type of template key, used to validate templates at startup
+var templateEvents = map[string]reflect.Type{
+	"pull_request":                reflect.TypeOf(github.PullRequestEvent{}),
+	pullRequestThreadTemplate:     reflect.TypeOf(github.PullRequestEvent{}),
+	"issue_comment":               reflect.TypeOf(github.IssueCommentEvent{}),
+	"pull_request_review_comment": reflect.TypeOf(github.PullRequestReviewCommentEvent{}),
+	"pull_request_review":         reflect.TypeOf(github.PullRequestReviewEvent{}),
+	"push":                        reflect.TypeOf(github.PushEvent{}),
+	"issues":                      reflect.TypeOf(github.IssuesEvent{}),
+	"release":                     reflect.TypeOf(github.ReleaseEvent{}),
+	"create":                      reflect.TypeOf(github.CreateEvent{}),
+	"delete":                      reflect.TypeOf(github.DeleteEvent{}),
+	"status":                      reflect.TypeOf(github.StatusEvent{}),
+	"check_run":                   reflect.TypeOf(github.CheckRunEvent{}),
+	"check_suite":                 reflect.TypeOf(github.CheckSuiteEvent{}),
+	"deployment_status":           reflect.TypeOf(github.DeploymentStatusEvent{}),
+}
+
+// DefaultMessageTemplates is builtin templates keyed by "event" or "event.action".
+// "event.action" takes precedence over "event"
+var DefaultMessageTemplates = map[string]MessageTemplate{
+	"pull_request": {
+		Title: "{{.GetRepo.GetFullName}}: pull request {{.GetAction}}",
+		Text:  "{{chatNames .GetPullRequest.GetBody}}\n{{link .GetPullRequest.GetHTMLURL \"github\"}}",
+		Color: "{{stateColor (pullRequestState .GetPullRequest)}}",
+	},
+	pullRequestThreadTemplate: {
+		Title:     "[{{pullRequestState .GetPullRequest}}] {{.GetRepo.GetFullName}}#{{.GetPullRequest.GetNumber}} {{.GetPullRequest.GetTitle}}",
+		TitleLink: "{{.GetPullRequest.GetHTMLURL}}",
+		Text:      "{{chatNames .GetPullRequest.GetBody}}",
+		Color:     "{{stateColor (pullRequestState .GetPullRequest)}}",
+	},
+	"issue_comment": {
+		Title: "{{.GetRepo.GetFullName}}: issue comment created",
+		Text:  "issue owner: @{{chatName .GetIssue.GetUser.GetLogin}}\n{{chatNames .GetComment.GetBody}}\n{{link .GetComment.GetHTMLURL \"github\"}}",
+		Color: "{{color \"neutral\"}}",
+	},
+	"pull_request_review_comment": {
+		Title: "{{.GetRepo.GetFullName}}: review comment created",
+		Text:  "pull request owner: @{{chatName .GetPullRequest.GetUser.GetLogin}}\n{{chatNames .GetComment.GetBody}}\n{{link .GetComment.GetHTMLURL \"github\"}}",
+		Color: "{{color \"neutral\"}}",
+	},
+	"pull_request_review": {
+		Title:     "{{.GetRepo.GetFullName}}: pull request {{reviewState .GetReview.GetState}}",
+		TitleLink: "{{.GetReview.GetHTMLURL}}",
+		Text:      "pull request owner: @{{chatName .GetPullRequest.GetUser.GetLogin}}\n{{chatNames .GetReview.GetBody}}",
+		Color:     "{{stateColor .GetReview.GetState}}",
+	},
+	"push": {
+		Title:     "{{.GetRepo.GetFullName}}: {{len .Commits}} new commits pushed to {{trimPrefix \"refs/heads/\" .GetRef}}{{if .GetForced}} (force-pushed){{end}}",
+		TitleLink: "{{.GetCompare}}",
+		Text: "{{range $i, $commit := .Commits}}{{if lt $i 10}}" +
+			"{{link $commit.GetURL (printf \"`%s`\" (shortSHA $commit.GetID))}} {{firstLine $commit.GetMessage}} - {{chatName (commitAuthor $commit)}}\n" +
+			"{{end}}{{end}}{{if gt (len .Commits) 10}}and {{sub (len .Commits) 10}} more commits{{end}}",
+		Color: "{{color \"info\"}}",
+	},
+	"issues": {
+		Title:     "{{.GetRepo.GetFullName}}: issue {{.GetAction}}",
+		TitleLink: "{{.GetIssue.GetHTMLURL}}",
+		Text:      "#{{.GetIssue.GetNumber}} {{.GetIssue.GetTitle}}",
+		Color:     "{{stateColor .GetAction}}",
+	},
+	"issues.opened": {
+		Title:     "{{.GetRepo.GetFullName}}: issue opened",
+		TitleLink: "{{.GetIssue.GetHTMLURL}}",
+		Text:      "#{{.GetIssue.GetNumber}} {{.GetIssue.GetTitle}}\n{{chatNames .GetIssue.GetBody}}",
+		Color:     "{{color \"success\"}}",
+	},
+	"release": {
+		Title:     "{{.GetRepo.GetFullName}}: {{if .GetRelease.GetPrerelease}}pre-release{{else}}release{{end}} {{or .GetRelease.GetName .GetRelease.GetTagName}} published",
+		TitleLink: "{{.GetRelease.GetHTMLURL}}",
+		Text:      "{{chatNames .GetRelease.GetBody}}",
+		Color:     "{{color \"merged\"}}",
+	},
+	"create": {
+		Title:     "{{.GetRepo.GetFullName}}: {{.GetRefType}} {{.GetRef}} created",
+		TitleLink: "{{.GetRepo.GetHTMLURL}}/tree/{{.GetRef}}",
+		Color:     "{{color \"success\"}}",
+	},
+	"delete": {
+		Title: "{{.GetRepo.GetFullName}}: {{.GetRefType}} {{.GetRef}} deleted",
+		Color: "{{color \"neutral\"}}",
+	},
+	"status": {
+		Title:     "{{.GetRepo.GetFullName}}: commit {{shortSHA .GetSHA}} {{.GetState}}",
+		TitleLink: "{{.GetTargetURL}}",
+		Text:      "{{.GetContext}}: {{.GetDescription}}{{if .Branches}}\nbranch: {{range $i, $branch := .Branches}}{{if $i}}, {{end}}{{$branch.GetName}}{{end}}{{end}}",
+		Color:     "{{stateColor .GetState}}",
+	},
+	"check_run": {
+		Title:     "{{.GetRepo.GetFullName}}: check {{.GetCheckRun.GetName}} {{.GetCheckRun.GetConclusion}}",
+		TitleLink: "{{.GetCheckRun.GetHTMLURL}}",
+		Text:      "branch: {{.GetCheckRun.GetCheckSuite.GetHeadBranch}}\ncommit: {{shortSHA .GetCheckRun.GetHeadSHA}}",
+		Color:     "{{stateColor .GetCheckRun.GetConclusion}}",
+	},
+	"check_suite": {
+		Title:     "{{.GetRepo.GetFullName}}: check suite {{.GetCheckSuite.GetApp.GetName}} {{.GetCheckSuite.GetConclusion}}",
+		TitleLink: "{{.GetRepo.GetHTMLURL}}/commit/{{.GetCheckSuite.GetHeadSHA}}",
+		Text:      "branch: {{.GetCheckSuite.GetHeadBranch}}\ncommit: {{shortSHA .GetCheckSuite.GetHeadSHA}}",
+		Color:     "{{stateColor .GetCheckSuite.GetConclusion}}",
+	},
+	"deployment_status": {
+		Title:     "{{.GetRepo.GetFullName}}: deploy to {{.GetDeployment.GetEnvironment}} {{.GetDeploymentStatus.GetState}}",
+		TitleLink: "{{.GetDeploymentStatus.GetTargetURL}}",
+		Text:      "ref: {{.GetDeployment.GetRef}} ({{shortSHA .GetDeployment.GetSHA}}){{with .GetDeploymentStatus.GetDescription}}\n{{.}}{{end}}",
+		Color:     "{{stateColor .GetDeploymentStatus.GetState}}",
+	},
+}
+
+// LoadMessageTemplates is read templates file, a JSON object of destination -> key -> template.
+// destination "*" overrides templates of every destination
+func LoadMessageTemplates(filename string) (map[string]map[string]MessageTemplate, error) {
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	templates := map[string]map[string]MessageTemplate{}
+	if err := json.Unmarshal(b, &templates); err != nil {
+		return nil, fmt.Errorf("%s: %s", filename, err.Error())
+	}
+	return templates, nil
+}
+
+type compiledTemplate struct {
+	key       string
+	title     *template.Template
+	titleLink *template.Template
+	text      *template.Template
+	color     *template.Template
+}
+
+// templateSet is compiled templates. destination "" is default templates
+type templateSet map[string]map[string]*compiledTemplate
+
+func (p *program) templateFuncs() template.FuncMap {
+	funcs := template.FuncMap{
+		"truncate":         truncate,
+		"link":             link,
+		"firstLine":        firstLine,
+		"shortSHA":         shortSHA,
+		"trimPrefix":       func(prefix, s string) string { return strings.TrimPrefix(s, prefix) },
+		"join":             func(sep string, s []string) string { return strings.Join(s, sep) },
+		"sub":              func(a, b int) int { return a - b },
+		"color":            func(name string) string { return colorNames[name] },
+		"stateColor":       stateColor,
+		"reviewState":      reviewStateLabel,
+		"pullRequestState": pullRequestState,
+		"commitAuthor":     commitAuthor,
+	}
+	for name, fn := range p.chatNameFuncs("") {
+		funcs[name] = fn
+	}
+	return funcs
+}
+
+// chatNameFuncs is template functions converting github names to chat names on adapter of destination
+func (p *program) chatNameFuncs(adapter string) template.FuncMap {
+	return template.FuncMap{
+		"chatName": func(githubName string) string {
+			return p.convertGithubName(adapter, githubName)
+		},
+		"chatNames": func(text string) string {
+			return p.convertGithubNamesInText(adapter, text)
+		},
+	}
+}
+
+// truncate is shorten text to n runes with ellipsis
+func truncate(n int, text string) string {
+	runes := []rune(text)
+	if n < 0 || len(runes) <= n {
+		return text
+	}
+	return string(runes[:n]) + "…"
+}
+
+func link(url, text string) string {
+	if url == "" {
+		return text
+	}
+	return "<" + url + "|" + text + ">"
+}
+
+// compileTemplates is parse default templates and destination overrides, and check them against event type
+// so that unknown fields and functions fail at startup
+func compileTemplates(funcs template.FuncMap, defaults map[string]MessageTemplate, overrides map[string]map[string]MessageTemplate) (templateSet, error) {
+	set := templateSet{}
+	all := map[string]map[string]MessageTemplate{"": defaults}
+	for destination, templates := range overrides {
+		if destination == "" {
+			return nil, fmt.Errorf("githubnotifier: template destination must not be empty")
+		}
+		all[destination] = templates
+	}
+	destinations := make([]string, 0, len(all))
+	for destination := range all {
+		destinations = append(destinations, destination)
+	}
+	sort.Strings(destinations)
+	for _, destination := range destinations {
+		set[destination] = map[string]*compiledTemplate{}
+		for key, source := range all[destination] {
+			compiled, err := compileTemplate(funcs, key, source)
+			if err != nil {
+				if destination != "" {
+					return nil, fmt.Errorf("githubnotifier: template %s of %s: %s", key, destination, err.Error())
+				}
+				return nil, fmt.Errorf("githubnotifier: template %s: %s", key, err.Error())
+			}
+			set[destination][key] = compiled
+		}
+	}
+	return set, nil
+}
+
+func compileTemplate(funcs template.FuncMap, key string, source MessageTemplate) (*compiledTemplate, error) {
+	eventType, exist := templateEvents[strings.SplitN(key, ".", 2)[0]]
+	if !exist {
+		return nil, fmt.Errorf("unknown event type")
+	}
+	compiled := &compiledTemplate{key: key}
+	fields := []struct {
+		name     string
+		source   string
+		template **template.Template
+	}{
+		{"title", source.Title, &compiled.title},
+		{"title_link", source.TitleLink, &compiled.titleLink},
+		{"text", source.Text, &compiled.text},
+		{"color", source.Color, &compiled.color},
+	}
+	empty := reflect.New(eventType).Interface()
+	for _, field := range fields {
+		t, err := template.New(key + "." + field.name).Funcs(funcs).Parse(field.source)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkTemplate(t, funcs, reflect.PtrTo(eventType)); err != nil {
+			return nil, err
+		}
+		// empty event has nil fields, so only nil pointer access is allowed here. names are checked above
+		if err := t.Execute(ioutil.Discard, empty); err != nil && !strings.Contains(err.Error(), "nil pointer evaluating") {
+			return nil, err
+		}
+		*field.template = t
+	}
+	return compiled, nil
+}
+
+// find is return templates of keys in order of precedence: destination, "*" and default
+func (s templateSet) find(destination string, keys []string) []*compiledTemplate {
+	destinations := []string{allDestinations, ""}
+	if destination != "" && destination != allDestinations {
+		destinations = append([]string{destination}, destinations...)
+	}
+	var found []*compiledTemplate
+	for _, d := range destinations {
+		templates, exist := s[d]
+		if !exist {
+			continue
+		}
+		for _, key := range keys {
+			if t, exist := templates[key]; exist {
+				found = append(found, t)
+				break
+			}
+		}
+	}
+	return found
+}
+
+// execute is render event into message. funcs replace functions of compiled template for this execution
+func (t *compiledTemplate) execute(event interface{}, message *glados.ChatMessage, funcs template.FuncMap) error {
+	fields := []struct {
+		template *template.Template
+		value    *string
+	}{
+		{t.title, &message.Title},
+		{t.titleLink, &message.TitleLinkURL},
+		{t.text, &message.Text},
+		{t.color, &message.Color},
+	}
+	for _, field := range fields {
+		tmpl, err := field.template.Clone()
+		if err != nil {
+			return err
+		}
+		var buf bytes.Buffer
+		if err := tmpl.Funcs(funcs).Execute(&buf, event); err != nil {
+			return err
+		}
+		*field.value = strings.TrimSpace(buf.String())
+	}
+	return nil
+}
+
+func (p *program) renderMessage(destination string, keys []string, event interface{}, author *github.User) *glados.ChatMessage {
+	adapter := destinationAdapterName(destination)
+	funcs := p.chatNameFuncs(adapter)
+	for _, t := range p.templates.find(destination, keys) {
+		message := &glados.ChatMessage{Author: p.buildAuthor(adapter, author)}
+		if err := t.execute(event, message, funcs); err != nil {
+			p.context.Logger().Warnln("githubnotifier: execute template " + t.key + " failed. " + err.Error())
+			continue
+		}
+		return message
+	}
+	return nil
+}
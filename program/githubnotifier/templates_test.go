@@ -0,0 +1,64 @@
+package githubnotifier
+
+import (
+	"strings"
+	"testing"
+	"text/template"
+)
+
+// testTemplateFuncs is template functions of program without name directory
+func testTemplateFuncs() template.FuncMap {
+	funcs := (&program{}).templateFuncs()
+	funcs["chatName"] = func(githubName string) string { return githubName }
+	funcs["chatNames"] = func(text string) string { return text }
+	return funcs
+}
+
+func TestCompileDefaultTemplates(t *testing.T) {
+	if _, err := compileTemplates(testTemplateFuncs(), DefaultMessageTemplates, nil); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCompileTemplate(t *testing.T) {
+	tests := []struct {
+		key  string
+		text string
+		err  string
+	}{
+		{"push", "{{range .Commits}}{{.GetID}} {{firstLine .GetMessage}}{{end}}", ""},
+		{"push", "{{range $i, $commit := .Commits}}{{$i}} {{$commit.GetAuthor.GetName}}{{end}}", ""},
+		{"push", "{{with .GetRepo}}{{.GetFullName}}{{else}}{{.GetRef}}{{end}}", ""},
+		{"pull_request", "{{.Repo.Owner.Login}}", ""},
+		{"push", "{{$repo := .GetRepo}}{{$repo.GetName}} {{$.GetRef}}", ""},
+		{"push", "{{.GetRef | trimPrefix \"refs/heads/\" | truncate 10}}", ""},
+		{"push", "{{if gt (len .Commits) 10}}{{sub (len .Commits) 10}}{{end}}", ""},
+		{"release", "{{or .GetRelease.GetName .GetRelease.GetTagName}}", ""},
+		{"pull_request.merged", "{{(pullRequestState .GetPullRequest) | stateColor}}", ""},
+
+		{"push", "{{.GetRepo.GetFulName}}", "GetFulName"},
+		{"pull_request", "{{.Repo.Owner.Lgin}}", "Lgin"},
+		{"push", "{{range .Commits}}{{.GetMesage}}{{end}}", "GetMesage"},
+		{"push", "{{range $commit := .Commits}}{{$commit.GetAuthor.GetNam}}{{end}}", "GetNam"},
+		{"push", "{{if .GetForced}}{{.GetRepo.GetHTMLUrl}}{{end}}", "GetHTMLUrl"},
+		{"push", "{{with .GetRepo}}{{.GetRef}}{{end}}", "GetRef"},
+		{"push", "{{range .GetRef}}{{.}}{{end}}", "range"},
+		{"push", "{{truncate .GetRef}}", "wrong number of args for truncate"},
+		{"push", "{{.GetRef | shortSHA 7}}", "wrong number of args for shortSHA"},
+		{"push", "{{unknownFunc .GetRef}}", "not defined"},
+		{"unknown", "{{.GetRef}}", "unknown event type"},
+	}
+	funcs := testTemplateFuncs()
+	for _, test := range tests {
+		_, err := compileTemplate(funcs, test.key, MessageTemplate{Text: test.text})
+		if test.err == "" {
+			if err != nil {
+				t.Errorf("%s %q failed. %s", test.key, test.text, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("%s %q error = %v, want %q", test.key, test.text, err, test.err)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package githubnotifier
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/astronoka/glados"
+	"github.com/google/go-github/github"
+)
+
+const threadNamespace = "githubnotifier.threads"
+
+// GitHubThreadRootConverter is optional converter interface building thread root message
+// which shows current state of pull request
+type GitHubThreadRootConverter interface {
+	ConvertEventToThreadRootMessage(destination string, event interface{}) *glados.ChatMessage
+}
+
+type pullRequestThread struct {
+	Channel   string
+	MessageID string
+	// RootTemplate is true if root is posted from thread root template, and may be updated with state of pull request
+	RootTemplate bool `json:",omitempty"`
+}
+
+// threadLocker is converter serializing thread creation, so that concurrent events of a new pull request share one root.
+// threads of other converters are not serialized
+type threadLocker interface {
+	threadLock() sync.Locker
+}
+
+type noLock struct{}
+
+func (noLock) Lock()   {}
+func (noLock) Unlock() {}
+
+func threadLockOf(converter GitHubEventConverter) sync.Locker {
+	if locker, ok := converter.(threadLocker); ok {
+		return locker.threadLock()
+	}
+	return noLock{}
+}
+
+// notifyEventToThread is post message to thread of pull request.
+// thread root is posted from root message of converter if any, otherwise first message is root.
+// failure to update root message is only logged, because retrying would post the reply again
+func notifyEventToThread(context glados.Context, destination, key string, event interface{}, message *glados.ChatMessage, converter GitHubEventConverter) error {
+	var root *glados.ChatMessage
+	if rootConverter, ok := converter.(GitHubThreadRootConverter); ok {
+		root = rootConverter.ConvertEventToThreadRootMessage(destination, event)
+	}
+
+	adapter, channel, err := destinationChatAdapter(context, destination)
+	if err != nil {
+		return err
+	}
+
+	lock := threadLockOf(converter)
+	lock.Lock()
+	thread := pullRequestThread{}
+	storageKey := destination + ":" + key
+	exist, err := context.Storage().Load(threadNamespace, storageKey, &thread)
+	if err != nil {
+		lock.Unlock()
+		return err
+	}
+	if !exist {
+		defer lock.Unlock()
+		first := message
+		if root != nil {
+			first = root
+		}
+		ref, err := adapter.PostMessage(channel, first)
+		if err != nil {
+			return err
+		}
+		thread = pullRequestThread{
+			Channel:      ref.Channel,
+			MessageID:    ref.ID,
+			RootTemplate: root != nil,
+		}
+		if err := context.Storage().Save(threadNamespace, storageKey, thread); err != nil {
+			context.Logger().Warnln("githubnotifier: save thread failed. " + err.Error())
+		}
+		if _, ok := event.(*github.PullRequestEvent); ok || root == nil {
+			// root shows the event
+			return nil
+		}
+		_, err = adapter.PostThreadMessage(thread.Channel, thread.MessageID, message)
+		return err
+	}
+	lock.Unlock()
+
+	_, err = adapter.PostThreadMessage(thread.Channel, thread.MessageID, message)
+	if err != nil {
+		return err
+	}
+	if root != nil && thread.RootTemplate && updatesThreadRoot(event) {
+		err = adapter.UpdateMessage(glados.ChatMessageRef{
+			Channel: thread.Channel,
+			ID:      thread.MessageID,
+		}, root)
+		if err != nil {
+			context.Logger().Warnln("githubnotifier: update thread root failed. " + err.Error())
+		}
+	}
+	return nil
+}
+
+// updatesThreadRoot is return true if event carries whole pull request. issue comment lacks merged state
+func updatesThreadRoot(event interface{}) bool {
+	_, ok := event.(*github.IssueCommentEvent)
+	return !ok
+}
+
+// pullRequestThreadKey is return "owner/repo#number" if event belongs to pull request
+func pullRequestThreadKey(event interface{}) (string, bool) {
+	switch event := event.(type) {
+	case *github.PullRequestEvent:
+		return pullRequestKey(event.GetRepo(), event.GetPullRequest().GetNumber())
+	case *github.IssueCommentEvent:
+		if event.GetIssue().GetPullRequestLinks() == nil {
+			return "", false
+		}
+		return pullRequestKey(event.GetRepo(), event.GetIssue().GetNumber())
+	case *github.PullRequestReviewCommentEvent:
+		return pullRequestKey(event.GetRepo(), event.GetPullRequest().GetNumber())
+	case *github.PullRequestReviewEvent:
+		return pullRequestKey(event.GetRepo(), event.GetPullRequest().GetNumber())
+	}
+	return "", false
+}
+
+func pullRequestKey(repo *github.Repository, number int) (string, bool) {
+	if repo.GetFullName() == "" || number == 0 {
+		return "", false
+	}
+	return fmt.Sprintf("%s#%d", repo.GetFullName(), number), true
+}
@@ -0,0 +1,224 @@
+package glados
+
+import (
+	"sort"
+	"strings"
+)
+
+const (
+	roleNamespace = "glados.roles"
+	// RoleAdmin is role allowed to run every handler and grant roles
+	RoleAdmin = "admin"
+)
+
+// RequireRole is allow handler only to users having any of roles. admin is always allowed
+func RequireRole(roles ...string) HandlerOption {
+	return func(info *HandlerInfo) {
+		info.Roles = append(info.Roles, roles...)
+	}
+}
+
+// AllowChannels is allow handler only in channels
+func AllowChannels(channels ...string) HandlerOption {
+	return func(info *HandlerInfo) {
+		for _, channel := range channels {
+			info.Channels = append(info.Channels, strings.TrimPrefix(channel, "#"))
+		}
+	}
+}
+
+func normalizeUser(user string) string {
+	return strings.ToLower(strings.TrimPrefix(user, "@"))
+}
+
+// QualifiedUser is return user key qualified by chat adapter name such as "slack:alice",
+// because same user name may be another user on another adapter.
+// user already qualified as "adapter:user" is kept, and empty adapter means default adapter
+func (d *Dispatcher) QualifiedUser(adapter, user string) string {
+	user = normalizeUser(strings.TrimSpace(user))
+	if strings.Contains(user, ":") {
+		return user
+	}
+	if adapter == "" {
+		adapter = d.context.ChatAdapterName(d.context.ChatAdapter())
+	}
+	return adapter + ":" + user
+}
+
+// Roles is return roles granted to user qualified by adapter name. bare user is user of default adapter.
+// users in GLADOS_ADMINS ("user" of default adapter or "adapter:user") have admin role
+func (d *Dispatcher) Roles(user string) ([]string, error) {
+	user = d.QualifiedUser("", user)
+	var roles []string
+	if _, err := d.context.Storage().Load(roleNamespace, user, &roles); err != nil {
+		return nil, err
+	}
+	for _, admin := range strings.Split(d.context.Env("GLADOS_ADMINS", ""), ",") {
+		if admin = strings.TrimSpace(admin); admin != "" && d.QualifiedUser("", admin) == user {
+			roles = appendRole(roles, RoleAdmin)
+		}
+	}
+	sort.Strings(roles)
+	return roles, nil
+}
+
+// HasRole is return true if user has role or admin role
+func (d *Dispatcher) HasRole(user, role string) (bool, error) {
+	roles, err := d.Roles(user)
+	if err != nil {
+		return false, err
+	}
+	for _, r := range roles {
+		if r == role || r == RoleAdmin {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// GrantRole is grant role to user qualified by adapter name
+func (d *Dispatcher) GrantRole(user, role string) error {
+	user = d.QualifiedUser("", user)
+	d.roleMutex.Lock()
+	defer d.roleMutex.Unlock()
+	var roles []string
+	if _, err := d.context.Storage().Load(roleNamespace, user, &roles); err != nil {
+		return err
+	}
+	return d.context.Storage().Save(roleNamespace, user, appendRole(roles, role))
+}
+
+// RevokeRole is revoke role from user qualified by adapter name. admin role of GLADOS_ADMINS can not be revoked
+func (d *Dispatcher) RevokeRole(user, role string) error {
+	user = d.QualifiedUser("", user)
+	d.roleMutex.Lock()
+	defer d.roleMutex.Unlock()
+	var roles []string
+	if _, err := d.context.Storage().Load(roleNamespace, user, &roles); err != nil {
+		return err
+	}
+	rest := []string{}
+	for _, r := range roles {
+		if r != role {
+			rest = append(rest, r)
+		}
+	}
+	if len(rest) <= 0 {
+		return d.context.Storage().Delete(roleNamespace, user)
+	}
+	return d.context.Storage().Save(roleNamespace, user, rest)
+}
+
+func appendRole(roles []string, role string) []string {
+	for _, r := range roles {
+		if r == role {
+			return roles
+		}
+	}
+	return append(roles, role)
+}
+
+// authorize is return true if user may run handler in channel.
+// denied attempt is replied to the user and recorded to audit
+func (d *Dispatcher) authorize(adapter ChatAdapter, info HandlerInfo, channel, user string) bool {
+	reason := ""
+	if len(info.Channels) > 0 && !containsFold(info.Channels, strings.TrimPrefix(channel, "#")) {
+		reason = "it is not allowed in this channel"
+	}
+	if reason == "" && len(info.Roles) > 0 {
+		allowed := false
+		qualified := d.QualifiedUser(d.context.ChatAdapterName(adapter), user)
+		for _, role := range info.Roles {
+			has, err := d.HasRole(qualified, role)
+			if err != nil {
+				d.context.Logger().Warnln("glados: load roles failed. " + err.Error())
+				break
+			}
+			allowed = allowed || has
+		}
+		if !allowed {
+			reason = "it requires " + strings.Join(info.Roles, " or ") + " role"
+		}
+	}
+	if reason == "" {
+		return true
+	}
+	d.context.Audit().Record(AuditEntry{
+		Kind:    AuditDenied,
+		Adapter: d.context.ChatAdapterName(adapter),
+		User:    user,
+		Channel: channel,
+		Handler: auditHandlerName(info),
+		Detail:  reason,
+	})
+	err := adapter.PostEphemeralMessage(channel, user, &ChatMessage{
+		Text: "Sorry @" + strings.TrimPrefix(user, "@") + ", " + reason + ".",
+	})
+	if err != nil {
+		d.context.Logger().Warnln("glados: post message failed. " + err.Error())
+	}
+	return false
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func (d *Dispatcher) registerRoleCommands() {
+	d.Command("role grant <user> <role>", func(adapter ChatAdapter, message *ChatMessageEvent, args *CommandArgs) {
+		user := d.QualifiedUser(message.Adapter, args.String("user"))
+		if err := d.GrantRole(user, args.String("role")); err != nil {
+			d.context.Logger().Warnln("glados: save roles failed. " + err.Error())
+			d.say(adapter, message.Channel, "@"+message.User+" grant role failed")
+			return
+		}
+		d.context.Audit().Record(AuditEntry{
+			Kind:      AuditPrivileged,
+			Adapter:   message.Adapter,
+			User:      message.User,
+			Channel:   message.Channel,
+			Handler:   "role grant",
+			Arguments: user + " " + args.String("role"),
+		})
+		d.say(adapter, message.Channel, "granted "+args.String("role")+" to @"+user)
+	}, RequireRole(RoleAdmin), WithDescription("grant role to user"))
+	d.Command("role revoke <user> <role>", func(adapter ChatAdapter, message *ChatMessageEvent, args *CommandArgs) {
+		user := d.QualifiedUser(message.Adapter, args.String("user"))
+		if err := d.RevokeRole(user, args.String("role")); err != nil {
+			d.context.Logger().Warnln("glados: save roles failed. " + err.Error())
+			d.say(adapter, message.Channel, "@"+message.User+" revoke role failed")
+			return
+		}
+		d.context.Audit().Record(AuditEntry{
+			Kind:      AuditPrivileged,
+			Adapter:   message.Adapter,
+			User:      message.User,
+			Channel:   message.Channel,
+			Handler:   "role revoke",
+			Arguments: user + " " + args.String("role"),
+		})
+		d.say(adapter, message.Channel, "revoked "+args.String("role")+" from @"+user)
+	}, RequireRole(RoleAdmin), WithDescription("revoke role from user"))
+	d.Command("role list [<user>]", func(adapter ChatAdapter, message *ChatMessageEvent, args *CommandArgs) {
+		user := d.QualifiedUser(message.Adapter, message.User)
+		if args.Has("user") {
+			user = d.QualifiedUser(message.Adapter, args.String("user"))
+		}
+		roles, err := d.Roles(user)
+		if err != nil {
+			d.context.Logger().Warnln("glados: load roles failed. " + err.Error())
+			d.say(adapter, message.Channel, "@"+message.User+" load roles failed")
+			return
+		}
+		if len(roles) <= 0 {
+			d.say(adapter, message.Channel, "@"+user+" has no role")
+			return
+		}
+		d.say(adapter, message.Channel, "@"+user+" has "+strings.Join(roles, ", "))
+	}, WithDescription("show roles of user"))
+}
@@ -0,0 +1,46 @@
+package glados_test
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/astronoka/glados"
+	"github.com/astronoka/glados/gladostest"
+)
+
+func TestRolesAreQualifiedByAdapter(t *testing.T) {
+	os.Setenv("GLADOS_ADMINS", "root, ops:carol")
+	defer os.Unsetenv("GLADOS_ADMINS")
+	h := gladostest.New()
+	ops := h.AddChatAdapter("ops")
+	d := h.Context.Dispatcher()
+
+	ops.Say("dev", "carol", "glados role grant bob deployer")
+	if _, ok := ops.WaitMessages(1, time.Second); !ok {
+		t.Fatal("role grant is not replied")
+	}
+	tests := []struct {
+		user string
+		role string
+		want bool
+	}{
+		{"root", glados.RoleAdmin, true},
+		{"default:root", glados.RoleAdmin, true},
+		{"ops:root", glados.RoleAdmin, false},
+		{"ops:carol", glados.RoleAdmin, true},
+		{"carol", glados.RoleAdmin, false},
+		{"ops:bob", "deployer", true},
+		{"@ops:Bob", "deployer", true},
+		{"bob", "deployer", false},
+	}
+	for _, test := range tests {
+		got, err := d.HasRole(test.user, test.role)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != test.want {
+			t.Errorf("HasRole(%q, %q) = %v, want %v", test.user, test.role, got, test.want)
+		}
+	}
+}
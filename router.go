@@ -1,5 +1,12 @@
 package glados
 
+import (
+	"context"
+	"crypto/subtle"
+	"net/http"
+	"strings"
+)
+
 // RequestHandler is golados request interface
 type RequestHandler func(RequestContext)
 
@@ -8,4 +15,20 @@ type Router interface {
 	GET(string, RequestHandler)
 	POST(string, RequestHandler)
 	RunWithPort(string)
+	Shutdown(context.Context) error
+}
+
+// RequireToken is wrap handler to require "Authorization: Bearer <token>" header.
+// every request is rejected if token is empty
+func RequireToken(token string, handler RequestHandler) RequestHandler {
+	return func(rc RequestContext) {
+		given := strings.TrimPrefix(rc.Request().Header.Get("Authorization"), "Bearer ")
+		if token == "" || subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			rc.JSON(http.StatusUnauthorized, H{
+				"message": "glados: invalid admin token",
+			})
+			return
+		}
+		handler(rc)
+	}
 }
@@ -1,8 +1,10 @@
 package ginbind
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/astronoka/glados"
@@ -13,7 +15,7 @@ import (
 func NewRouter(c glados.Context) glados.Router {
 	engine := gin.New()
 	engine.Use(accessLogger(c.Logger()), gin.Recovery())
-	return ginRouter{
+	return &ginRouter{
 		context: c,
 		engine:  engine,
 	}
@@ -42,24 +44,53 @@ func accessLogger(logger glados.Logger) gin.HandlerFunc {
 }
 
 type ginRouter struct {
-	context glados.Context
-	engine  *gin.Engine
+	mu       sync.Mutex
+	context  glados.Context
+	engine   *gin.Engine
+	server   *http.Server
+	shutdown bool
 }
 
-func (router ginRouter) GET(path string, handler glados.RequestHandler) {
+func (router *ginRouter) GET(path string, handler glados.RequestHandler) {
 	router.engine.GET(path, func(ginContext *gin.Context) {
 		handler(ginContextWrapper{ginContext})
 	})
 }
 
-func (router ginRouter) POST(path string, handler glados.RequestHandler) {
+func (router *ginRouter) POST(path string, handler glados.RequestHandler) {
 	router.engine.POST(path, func(ginContext *gin.Context) {
 		handler(ginContextWrapper{ginContext})
 	})
 }
 
-func (router ginRouter) RunWithPort(port string) {
-	router.engine.Run(":" + port)
+func (router *ginRouter) RunWithPort(port string) {
+	server := &http.Server{
+		Addr:    ":" + port,
+		Handler: router.engine,
+	}
+	router.mu.Lock()
+	if router.shutdown {
+		// Shutdown is called before server starts
+		router.mu.Unlock()
+		return
+	}
+	router.server = server
+	router.mu.Unlock()
+	err := server.ListenAndServe()
+	if err != nil && err != http.ErrServerClosed {
+		router.context.Logger().Errorln("ginbind: listen failed. " + err.Error())
+	}
+}
+
+func (router *ginRouter) Shutdown(ctx context.Context) error {
+	router.mu.Lock()
+	router.shutdown = true
+	server := router.server
+	router.mu.Unlock()
+	if server == nil {
+		return nil
+	}
+	return server.Shutdown(ctx)
 }
 
 type ginContextWrapper struct {
@@ -0,0 +1,184 @@
+package glados
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// JobHandler is scheduled job callback
+type JobHandler func(Context)
+
+const schedulerNamespace = "glados.scheduler"
+
+type scheduledJob struct {
+	name     string
+	schedule Schedule
+	handle   JobHandler
+}
+
+type jobState struct {
+	LastRun time.Time
+}
+
+// Scheduler is run registered jobs on schedule.
+// last run time of each job is saved to Storage, so restart does not skip or duplicate a run.
+// it can be started again after Stop
+type Scheduler struct {
+	mu      sync.Mutex
+	context Context
+	jobs    map[string]*scheduledJob
+	running bool
+	// stop is closed by Stop, and made again by Start
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewScheduler is create scheduler instance
+func NewScheduler(c Context) *Scheduler {
+	return &Scheduler{
+		context: c,
+		jobs:    map[string]*scheduledJob{},
+	}
+}
+
+// Cron is register job run on cron expression in location. nil location means time.Local
+func (s *Scheduler) Cron(name, spec string, location *time.Location, handler JobHandler) error {
+	schedule, err := ParseCron(spec, location)
+	if err != nil {
+		return err
+	}
+	return s.Schedule(name, schedule, handler)
+}
+
+// Every is register job run every interval
+func (s *Scheduler) Every(name string, interval time.Duration, handler JobHandler) error {
+	if interval <= 0 {
+		return fmt.Errorf("glados: job %s interval must be positive", name)
+	}
+	return s.Schedule(name, IntervalSchedule(interval), handler)
+}
+
+// Schedule is register job run on schedule. name must be unique, it is the key of saved state
+func (s *Scheduler) Schedule(name string, schedule Schedule, handler JobHandler) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, exist := s.jobs[name]; exist {
+		return fmt.Errorf("glados: job %s is already scheduled", name)
+	}
+	job := &scheduledJob{
+		name:     name,
+		schedule: schedule,
+		handle:   handler,
+	}
+	s.jobs[name] = job
+	if s.running {
+		s.startJob(job, s.stop)
+	}
+	return nil
+}
+
+// Start is start running registered jobs
+func (s *Scheduler) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.running {
+		return
+	}
+	s.running = true
+	s.stop = make(chan struct{})
+	for _, job := range s.jobs {
+		s.startJob(job, s.stop)
+	}
+}
+
+// Stop is stop scheduling and wait for running jobs
+func (s *Scheduler) Stop(ctx context.Context) error {
+	s.mu.Lock()
+	if !s.running {
+		s.mu.Unlock()
+		return nil
+	}
+	s.running = false
+	close(s.stop)
+	s.mu.Unlock()
+	stopped := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *Scheduler) startJob(job *scheduledJob, stop <-chan struct{}) {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.runLoop(job, stop)
+	}()
+}
+
+func (s *Scheduler) runLoop(job *scheduledJob, stop <-chan struct{}) {
+	logger := s.context.Logger()
+	lastRun := s.loadLastRun(job)
+	for {
+		now := time.Now()
+		var next time.Time
+		if lastRun.IsZero() {
+			next = job.schedule.Next(now)
+		} else {
+			next = job.schedule.Next(lastRun)
+		}
+		if next.IsZero() {
+			logger.Warnln("glados: job " + job.name + " has no next run time")
+			return
+		}
+		if next.Before(now) {
+			// missed while stopped. run once now
+			next = now
+		}
+		timer := time.NewTimer(next.Sub(now))
+		select {
+		case <-stop:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		lastRun = next
+		s.saveLastRun(job, lastRun)
+		s.runJob(job)
+	}
+}
+
+func (s *Scheduler) runJob(job *scheduledJob) {
+	logger := s.context.Logger()
+	defer func() {
+		if r := recover(); r != nil {
+			logger.Errorf("glados: job %s panic. %v", job.name, r)
+		}
+	}()
+	logger.Debugln("glados: run job " + job.name)
+	job.handle(s.context)
+}
+
+func (s *Scheduler) loadLastRun(job *scheduledJob) time.Time {
+	state := jobState{}
+	_, err := s.context.Storage().Load(schedulerNamespace, job.name, &state)
+	if err != nil {
+		s.context.Logger().Warnln("glados: load job state failed. " + err.Error())
+	}
+	return state.LastRun
+}
+
+func (s *Scheduler) saveLastRun(job *scheduledJob, lastRun time.Time) {
+	err := s.context.Storage().Save(schedulerNamespace, job.name, jobState{LastRun: lastRun})
+	if err != nil {
+		s.context.Logger().Warnln("glados: save job state failed. " + err.Error())
+	}
+}
@@ -0,0 +1,79 @@
+package glados_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/astronoka/glados"
+	"github.com/astronoka/glados/gladostest"
+)
+
+func TestSchedulerStartAfterStop(t *testing.T) {
+	h := gladostest.New()
+	s := h.Context.Scheduler()
+	var runs int32
+	if err := s.Every("tick", 10*time.Millisecond, func(glados.Context) {
+		atomic.AddInt32(&runs, 1)
+	}); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		before := atomic.LoadInt32(&runs)
+		s.Start()
+		deadline := time.Now().Add(time.Second)
+		for atomic.LoadInt32(&runs) == before && time.Now().Before(deadline) {
+			time.Sleep(5 * time.Millisecond)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		err := s.Stop(ctx)
+		cancel()
+		if err != nil {
+			t.Fatalf("Stop #%d returned %v", i+1, err)
+		}
+		if atomic.LoadInt32(&runs) == before {
+			t.Fatalf("job does not run after Start #%d", i+1)
+		}
+	}
+}
+
+func TestDialogExpiryScheduledOnFirstDialog(t *testing.T) {
+	h := gladostest.New()
+	d := h.Context.Dispatcher()
+	d.Respond(`deploy$`, func(adapter glados.ChatAdapter, message *glados.ChatMessageEvent) {
+		if err := d.StartDialog(adapter, message, "deploy", "env", "which env?"); err != nil {
+			t.Error(err)
+		}
+	})
+	d.DialogStep("deploy", "env", func(glados.ChatAdapter, *glados.ChatMessageEvent, *glados.Dialog) {})
+
+	if glados.Scheduled(h.Context.Scheduler(), glados.DialogExpiryJob) {
+		t.Fatal("dialog expiry is scheduled before dialog is used")
+	}
+	h.ChatAdapter.Say("dev", "alice", "glados deploy")
+	if _, ok := h.ChatAdapter.WaitMessages(1, time.Second); !ok {
+		t.Fatal("question is not asked")
+	}
+	if !glados.Scheduled(h.Context.Scheduler(), glados.DialogExpiryJob) {
+		t.Error("dialog expiry is not scheduled after dialog is started")
+	}
+
+	// dialog saved before restart is expired by restarted bot
+	restarted := gladostest.New()
+	restarted.Context.SetStorage(h.Context.Storage())
+	if glados.Scheduled(restarted.Context.Scheduler(), glados.DialogExpiryJob) {
+		t.Fatal("dialog expiry is scheduled before boot")
+	}
+	restarted.Boot()
+	deadline := time.Now().Add(time.Second)
+	for !glados.Scheduled(restarted.Context.Scheduler(), glados.DialogExpiryJob) && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if !glados.Scheduled(restarted.Context.Scheduler(), glados.DialogExpiryJob) {
+		t.Error("dialog expiry is not scheduled on boot with waiting dialog")
+	}
+	if err := restarted.Shutdown(); err != nil {
+		t.Fatal(err)
+	}
+}
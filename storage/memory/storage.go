@@ -3,6 +3,7 @@ package memory
 import (
 	"encoding/json"
 	"fmt"
+	"sync"
 
 	"github.com/astronoka/glados"
 )
@@ -15,6 +16,7 @@ func NewStorage(c glados.Context) glados.Storage {
 }
 
 type memoryStorage struct {
+	mu   sync.RWMutex
 	data map[string][]byte
 }
 
@@ -23,15 +25,20 @@ func (s *memoryStorage) Save(namespace, key string, value interface{}) error {
 	if err != nil {
 		return fmt.Errorf("memory : serialize %s:%s data faild. %s", namespace, key, err.Error())
 	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	s.data[namespace+":"+key] = b
 	return nil
 }
 
 func (s *memoryStorage) Load(namespace, key string, value interface{}) (bool, error) {
-	if _, exist := s.data[namespace+":"+key]; !exist {
+	s.mu.RLock()
+	b, exist := s.data[namespace+":"+key]
+	s.mu.RUnlock()
+	if !exist {
 		return false, nil
 	}
-	err := json.Unmarshal(s.data[namespace+":"+key], value)
+	err := json.Unmarshal(b, value)
 	if err != nil {
 		return false, fmt.Errorf("memory: deserialize %s:%s failed. %s", namespace, key, err.Error())
 	}
@@ -39,6 +46,8 @@ func (s *memoryStorage) Load(namespace, key string, value interface{}) (bool, er
 }
 
 func (s *memoryStorage) Delete(namespace, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	delete(s.data, namespace+":"+key)
 	return nil
 }
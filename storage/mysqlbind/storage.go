@@ -35,17 +35,21 @@ type mysqlStorage struct {
 	db      *gorm.DB
 }
 
+func (s *mysqlStorage) Close() error {
+	return s.db.Close()
+}
+
 func (s *mysqlStorage) Save(namespace, key string, value interface{}) error {
 	b, err := json.Marshal(value)
 	if err != nil {
 		return fmt.Errorf("mysqlbind: serialize %s:%s data faild. %s", namespace, key, err.Error())
 	}
-	item := Item{
+	where := Item{
 		Namespace: namespace,
 		Key:       key,
-		Value:     string(b),
 	}
-	err = s.db.Create(item).Error
+	item := Item{}
+	err = s.db.Where(where).Assign(Item{Value: string(b)}).FirstOrCreate(&item).Error
 	if err != nil {
 		return fmt.Errorf("mysqlbind: save %s:%s failed. %s", namespace, key, err.Error())
 	}